@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"wotd.go/wotd"
+)
+
+// ---------------------------
+// Word card (PNG)
+// ---------------------------
+
+// Whether scheduled posts attach a rendered card; set from RENDER_CARD in main.
+var renderCards bool
+
+const (
+	cardWidth   = 1000
+	cardHeight  = 500
+	cardMargin  = 60
+	cardMaxDefs = 5 // wrapped definition lines before cutting off
+)
+
+var (
+	cardBackground = color.RGBA{0x2b, 0x2d, 0x31, 0xff}
+	cardAccent     = color.RGBA{0x58, 0x65, 0xf2, 0xff}
+	cardText       = color.RGBA{0xf2, 0xf3, 0xf5, 0xff}
+	cardMuted      = color.RGBA{0xb5, 0xba, 0xc1, 0xff}
+)
+
+type cardFaces struct {
+	title, body, italic font.Face
+}
+
+// The Go fonts are compiled into the binary, so no font files are needed
+// at runtime. They don't cover IPA or emoji, so the card leaves out the
+// pronunciation and the header emoji.
+var loadCardFaces = sync.OnceValues(func() (cardFaces, error) {
+	face := func(ttf []byte, size float64) (font.Face, error) {
+		f, err := opentype.Parse(ttf)
+		if err != nil {
+			return nil, err
+		}
+		return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	}
+	var fs cardFaces
+	var err error
+	if fs.title, err = face(gobold.TTF, 72); err != nil {
+		return fs, err
+	}
+	if fs.body, err = face(goregular.TTF, 30); err != nil {
+		return fs, err
+	}
+	fs.italic, err = face(goitalic.TTF, 28)
+	return fs, err
+})
+
+// renderCard draws the word, part of speech and primary definition onto a PNG.
+func renderCard(w wotd.WordData) ([]byte, error) {
+	fs, err := loadCardFaces()
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(cardBackground), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 12, cardHeight), image.NewUniform(cardAccent), image.Point{}, draw.Src)
+
+	y := cardMargin + 60
+	drawText(img, fs.title, cardText, cardMargin, y, wotd.CapitalizeWord(w.Word))
+	meaning, def, ok := w.Primary()
+	if !ok {
+		y += 70
+		drawText(img, fs.italic, cardMuted, cardMargin, y, "(No definition found)")
+	} else {
+		if meaning.PartOfSpeech != "" {
+			y += 55
+			drawText(img, fs.italic, cardMuted, cardMargin, y, meaning.PartOfSpeech)
+		}
+		y += 25
+		lines := wrapText(fs.body, def.Definition, cardWidth-2*cardMargin)
+		if len(lines) > cardMaxDefs {
+			lines = lines[:cardMaxDefs]
+			lines[cardMaxDefs-1] = strings.TrimRight(lines[cardMaxDefs-1], " .,;:") + "…"
+		}
+		for _, line := range lines {
+			y += 42
+			drawText(img, fs.body, cardText, cardMargin, y, line)
+		}
+	}
+	drawText(img, fs.italic, cardAccent, cardMargin, cardHeight-cardMargin+20, wotd.Formatting.Header)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawText(dst draw.Image, face font.Face, c color.Color, x, y int, s string) {
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+// wrapText breaks s into lines no wider than width pixels.
+func wrapText(face font.Face, s string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if line != "" && font.MeasureString(face, next).Ceil() > width {
+			lines = append(lines, line)
+			next = word
+		}
+		line = next
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,1074 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"wotd.go/wotd"
+)
+
+// ---------------------------
+// Slash commands
+// ---------------------------
+
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "wotd",
+		Description: "Get a random Word of the Day",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "private",
+				Description: "Only show the word to you",
+			},
+		},
+	},
+	{
+		Name:        "random",
+		Description: "Get a random word with its definition",
+	},
+	{
+		Name:        "today",
+		Description: "Show today's Word of the Day again",
+	},
+	{
+		Name:        "define",
+		Description: "Look up the definition of a word",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "word",
+				Description:  "The word to define",
+				Required:     true,
+				Autocomplete: true,
+			},
+		},
+	},
+	{
+		Name:        "history",
+		Description: "Show recently posted words",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "How many words to show (default 10)",
+				MinValue:    &minHistoryCount,
+				MaxValue:    maxHistoryCount,
+			},
+		},
+	},
+	{
+		Name:                     "config",
+		Description:              "Configure this server's Word of the Day",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &falseValue,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set-channel",
+				Description: "Channel for the scheduled post",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Where to post",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set-time",
+				Description: "Time(s) of the scheduled post",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "time",
+						Description: "24h HH:MM, comma-separated for several posts",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "tz",
+						Description: "IANA timezone, e.g. America/New_York",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set-language",
+				Description: "Language of the words and definitions",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "language",
+						Description: "Language code",
+						Required:    true,
+						Choices:     languageChoices(),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set-difficulty",
+				Description: "How hard the words are",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "difficulty",
+						Description: "easy, medium or hard",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "easy", Value: "easy"},
+							{Name: "medium", Value: "medium"},
+							{Name: "hard", Value: "hard"},
+						},
+					},
+				},
+			},
+		},
+	},
+	{Name: "stats", Description: "Show bot uptime and how many words it has posted"},
+	{
+		Name:        "export",
+		Description: "Download the posted words to study later",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "format",
+				Description: "CSV (default) or Anki-compatible TSV",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "csv", Value: "csv"},
+					{Name: "anki", Value: "anki"},
+				},
+			},
+		},
+	},
+	{Name: "subscribe", Description: "Get the daily Word of the Day by DM"},
+	{Name: "unsubscribe", Description: "Stop getting the Word of the Day by DM"},
+	{
+		Name:                     "post",
+		Description:              "Send the scheduled Word of the Day now",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &falseValue,
+	},
+	{
+		Name:                     "setword",
+		Description:              "Pin the word for the next scheduled post",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &falseValue,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "word",
+				Description: "The word to post",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "feedback",
+		Description: "Report an inappropriate or broken word",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "word",
+				Description: "The word to report",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "reason",
+				Description: "What's wrong with it",
+				Required:    true,
+				MaxLength:   maxFeedbackReason,
+			},
+		},
+	},
+	{
+		Name:                     "nextpost",
+		Description:              "Show when the next scheduled Word of the Day will be posted",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &falseValue,
+	},
+	{
+		Name:        "help",
+		Description: "List the bot's commands",
+	},
+}
+
+var (
+	minHistoryCount  float64 = 1
+	adminPermissions int64   = discordgo.PermissionManageGuild
+	falseValue               = false
+)
+
+const (
+	defaultHistoryCount = 10
+	maxHistoryCount     = 25
+	maxFeedbackReason   = 300
+)
+
+// onInteraction routes every interaction the bot receives.
+func (b *bot) onInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.onCommand(s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		if i.ApplicationCommandData().Name == "define" {
+			autocompleteDefine(s, i, b.hist, b.historyKey(i.GuildID))
+		}
+	case discordgo.InteractionMessageComponent:
+		b.onComponent(s, i)
+	}
+}
+
+func (b *bot) onCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	opts := optionMap(data.Options)
+	switch data.Name {
+	case "wotd":
+		private := false
+		if opt, ok := opts["private"]; ok {
+			private = opt.BoolValue()
+		}
+		b.respondWOTD(s, i, private)
+	case "random":
+		b.random(s, i)
+	case "today":
+		b.today(s, i)
+	case "define":
+		word := strings.TrimSpace(opts["word"].StringValue())
+		b.define(s, i, word)
+	case "history":
+		count := defaultHistoryCount
+		if opt, ok := opts["count"]; ok {
+			count = int(opt.IntValue())
+		}
+		respondEphemeral(s, i, historyMessage(b.hist, b.historyKey(i.GuildID), count, b.cfg.location()))
+	case "config":
+		respondEphemeral(s, i, b.configCommand(i.GuildID, data.Options[0]))
+	case "post":
+		b.postNow(s, i)
+	case "setword":
+		word := strings.TrimSpace(opts["word"].StringValue())
+		b.setWord(s, i, word)
+	case "feedback":
+		word := strings.TrimSpace(opts["word"].StringValue())
+		reason := strings.TrimSpace(opts["reason"].StringValue())
+		respondEphemeral(s, i, b.feedback(interactionUser(i).ID, word, reason))
+	case "help":
+		respondEphemeral(s, i, helpMessage(commands))
+	case "nextpost":
+		respondEphemeral(s, i, b.nextPostMessage(i.GuildID, time.Now()))
+	case "stats":
+		respond(s, i, b.statsMessage())
+	case "export":
+		format := "csv"
+		if opt, ok := opts["format"]; ok {
+			format = opt.StringValue()
+		}
+		b.exportCommand(s, i, format)
+	case "subscribe", "unsubscribe":
+		respondEphemeral(s, i, b.subscription(interactionUser(i).ID, data.Name == "subscribe"))
+	}
+}
+
+func (b *bot) onComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	id := i.MessageComponentData().CustomID
+	switch {
+	case id == againButtonID:
+		b.onAgain(s, i)
+	case strings.HasPrefix(id, definePageID+":"):
+		b.onDefinePage(s, i, id)
+	}
+}
+
+func (b *bot) onAgain(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !b.again.Allow(interactionUser(i).ID) {
+		// Acknowledge without changing anything.
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+		return
+	}
+	b.respondWOTD(s, i, false)
+}
+
+// How long a command may spend on upstream lookups. It has to cover the
+// deferred reply only; Discord keeps the interaction open for 15 minutes.
+const lookupTimeout = 30 * time.Second
+
+// respondWOTD answers /wotd and the "Another word" button with a fresh word.
+// Finding one can take longer than Discord's 3 second deadline, so the
+// reply is deferred and filled in afterwards. A private reply is ephemeral
+// and has no "Another word" button, which would answer publicly.
+func (b *bot) respondWOTD(s *discordgo.Session, i *discordgo.InteractionCreate, private bool) {
+	if b.wotdLimit != nil {
+		if wait := b.wotdLimit.Wait(interactionUser(i).ID); wait > 0 {
+			respondEphemeral(s, i, fmt.Sprintf("Please wait %d seconds.", int(math.Ceil(wait.Seconds()))))
+			return
+		}
+	}
+	if err := deferReply(s, i, private); err != nil {
+		slog.Error("[wotd] could not defer reply", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	reply, shown := b.wotdReply(ctx, i.GuildID)
+	if private {
+		reply.Components = nil
+	}
+	if err := editReply(s, i, reply); err != nil {
+		slog.Error("[wotd] could not send reply", "err", err)
+		return
+	}
+	if shown {
+		b.recordPost(kindOnDemand)
+	}
+}
+
+// random answers /random: a fresh word like /wotd, but shown without the
+// Word of the Day header and without the "Another word" button.
+func (b *bot) random(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if err := deferReply(s, i, false); err != nil {
+		slog.Error("[random] could not defer reply", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(wotd.WithRequestID(context.Background()), lookupTimeout)
+	defer cancel()
+	p := b.prefsFor(i.GuildID)
+	w, ok := wotd.GetWOTD(ctx, b.cfg.WOTDRetries, wotd.NewSelector(p, b.hist, b.reportedFunc()), p.Lang)
+	var reply *discordgo.InteractionResponseData
+	switch {
+	case !ok:
+		reply = &discordgo.InteractionResponseData{Content: "⚠️ Couldn't find a word with a definition right now, try again."}
+	case b.cfg.PlainText || w.Word == "":
+		reply = &discordgo.InteractionResponseData{Content: wotd.FormatWord(w)}
+	default:
+		reply = &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{wotd.BuildWordEmbed(w)}}
+	}
+	if err := editReply(s, i, reply); err != nil {
+		slog.Error("[random] could not send reply", "err", err)
+		return
+	}
+	if ok && w.Word != "" {
+		b.recordPost(kindOnDemand)
+	}
+}
+
+// wotdReply is a fresh word with the "Another word" button attached. shown
+// reports whether the reply carries a word, for /stats.
+func (b *bot) wotdReply(ctx context.Context, guildID string) (data *discordgo.InteractionResponseData, shown bool) {
+	ctx = wotd.WithRequestID(ctx)
+	p := b.prefsFor(guildID)
+	w, ok := wotd.GetWOTD(ctx, b.cfg.WOTDRetries, wotd.NewSelector(p, b.hist, b.reportedFunc()), p.Lang)
+	if !ok {
+		return &discordgo.InteractionResponseData{Content: "⚠️ Couldn't find a word with a definition right now, try again."}, false
+	}
+	data = wotdResponse(w, b.cfg.PlainText)
+	data.Components = againButton()
+	return data, w.Word != ""
+}
+
+// deferReply acknowledges an interaction with a "thinking…" placeholder; an
+// ephemeral one makes the eventual reply visible only to the user.
+func deferReply(s *discordgo.Session, i *discordgo.InteractionCreate, ephemeral bool) error {
+	resp := &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredChannelMessageWithSource}
+	if ephemeral {
+		resp.Data = &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral}
+	}
+	return s.InteractionRespond(i.Interaction, resp)
+}
+
+// editReply replaces the placeholder left by deferReply.
+func editReply(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) error {
+	edit := &discordgo.WebhookEdit{Content: &data.Content}
+	if len(data.Embeds) > 0 {
+		edit.Embeds = &data.Embeds
+	}
+	if len(data.Components) > 0 {
+		edit.Components = &data.Components
+	}
+	_, err := s.InteractionResponseEdit(i.Interaction, edit)
+	return err
+}
+
+func optionMap(opts []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	m := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(opts))
+	for _, o := range opts {
+		m[o.Name] = o
+	}
+	return m
+}
+
+func respond(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg},
+	})
+}
+
+// respondEphemeral replies so only the invoking user sees the message.
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// /history: the last N words posted to the target with their post dates.
+func historyMessage(hist *History, target string, count int, loc *time.Location) string {
+	entries := hist.RecentWords(target, count)
+	if len(entries) == 0 {
+		return "No words posted yet."
+	}
+	lines := []string{"🗂️ Recent words:"}
+	for _, e := range entries {
+		lines = append(lines, fmt.Sprintf("• **%s** — %s", wotd.CapitalizeWord(e.Word), e.PostedAt.In(loc).Format("Mon Jan 2, 2006")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// /help: every command in cmds with its description, subcommands on lines of
+// their own, so the list follows whatever is registered.
+func helpMessage(cmds []*discordgo.ApplicationCommand) string {
+	lines := []string{"📋 Commands:"}
+	for _, c := range cmds {
+		admin := ""
+		if c.DefaultMemberPermissions != nil {
+			admin = " (admins)"
+		}
+		var subs []string
+		for _, o := range c.Options {
+			if o.Type == discordgo.ApplicationCommandOptionSubCommand {
+				subs = append(subs, fmt.Sprintf("• `/%s %s` — %s%s", c.Name, o.Name, o.Description, admin))
+			}
+		}
+		if len(subs) == 0 {
+			subs = []string{fmt.Sprintf("• `/%s` — %s%s", c.Name, c.Description, admin)}
+		}
+		lines = append(lines, subs...)
+	}
+	return wotd.FitMessage(strings.Join(lines, "\n"))
+}
+
+// Discord allows at most 25 autocomplete choices.
+const maxChoices = 25
+
+// Suggest words previously posted to target matching what the user has typed
+// so far.
+func autocompleteDefine(s *discordgo.Session, i *discordgo.InteractionCreate, hist *History, target string) {
+	var partial string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "word" && opt.Focused {
+			partial = opt.StringValue()
+		}
+	}
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, w := range hist.Matching(target, partial, maxChoices) {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: w, Value: w})
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+}
+
+// ---------------------------
+// "Another word" button
+// ---------------------------
+
+const againButtonID = "wotd_again"
+
+// Minimum time between button clicks per user.
+const againCooldown = 5 * time.Second
+
+func againButton() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Another word", Style: discordgo.SecondaryButton, CustomID: againButtonID, Emoji: &discordgo.ComponentEmoji{Name: "🔄"}},
+		}},
+	}
+}
+
+// interactionUser is the invoking user in guilds and DMs alike.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// clickLimiter ignores repeat actions from a user within a cooldown.
+type clickLimiter struct {
+	mu    sync.Mutex
+	every time.Duration
+	last  map[string]time.Time
+}
+
+func newClickLimiter(every time.Duration) *clickLimiter {
+	return &clickLimiter{every: every, last: map[string]time.Time{}}
+}
+
+func (l *clickLimiter) Allow(userID string) bool {
+	return l.Wait(userID) == 0
+}
+
+// Wait records the action and returns 0 if the user is past the cooldown,
+// else how much of it is left (and records nothing).
+func (l *clickLimiter) Wait(userID string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if t, ok := l.last[userID]; ok && now.Sub(t) < l.every {
+		return l.every - now.Sub(t)
+	}
+	l.last[userID] = now
+	// Forget stale users so the map doesn't grow forever.
+	for id, t := range l.last {
+		if now.Sub(t) >= l.every {
+			delete(l.last, id)
+		}
+	}
+	return 0
+}
+
+// ---------------------------
+// /define sense pages
+// ---------------------------
+
+const definePageID = "define_page"
+
+// How long Prev/Next keep working; interaction tokens last 15 minutes too.
+const definePagesTTL = 15 * time.Minute
+
+// sense is one definition with the part of speech it belongs to.
+type sense struct {
+	pos string
+	def wotd.Definition
+}
+
+// senses flattens every definition of every meaning, in order.
+func senses(w wotd.WordData) []sense {
+	var out []sense
+	for _, m := range w.Meanings {
+		for _, d := range m.Definitions {
+			out = append(out, sense{pos: m.PartOfSpeech, def: d})
+		}
+	}
+	return out
+}
+
+type definePage struct {
+	word    wotd.WordData
+	senses  []sense
+	created time.Time
+}
+
+// definePages keeps /define results keyed by the command's interaction ID so
+// the Prev/Next buttons can rebuild any page.
+type definePages struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	m   map[string]definePage
+}
+
+func newDefinePages(ttl time.Duration) *definePages {
+	return &definePages{ttl: ttl, m: map[string]definePage{}}
+}
+
+func (p *definePages) Put(key string, w wotd.WordData, ss []sense) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for k, pg := range p.m {
+		if now.Sub(pg.created) >= p.ttl {
+			delete(p.m, k)
+		}
+	}
+	p.m[key] = definePage{word: w, senses: ss, created: now}
+}
+
+func (p *definePages) Get(key string) (definePage, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pg, ok := p.m[key]
+	if !ok || time.Since(pg.created) >= p.ttl {
+		return definePage{}, false
+	}
+	return pg, true
+}
+
+// define answers /define, deferred like /wotd. Words with a single sense get
+// the usual one-shot reply; otherwise the first sense is shown with Prev/Next
+// buttons.
+func (b *bot) define(s *discordgo.Session, i *discordgo.InteractionCreate, word string) {
+	if err := deferReply(s, i, false); err != nil {
+		slog.Error("[define] could not defer reply", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	w, failed := wotd.DefineWord(ctx, word, b.prefsFor(i.GuildID).Lang)
+	reply := &discordgo.InteractionResponseData{Content: failed}
+	if ss := senses(w); failed == "" && len(ss) >= 2 {
+		b.pages.Put(i.ID, w, ss)
+		reply = sensePage(i.ID, w, ss, 0)
+	} else if failed == "" {
+		reply.Content = wotd.FitMessage(fmt.Sprintf("%s %s", wotd.Heading(w), wotd.FormatDefinition(w)) + wotd.SourceCredit(w))
+	}
+	if err := editReply(s, i, reply); err != nil {
+		slog.Error("[define] could not send reply", "word", word, "err", err)
+		return
+	}
+	if failed == "" {
+		b.recordPost(kindOnDemand)
+	}
+}
+
+// today re-shows the latest post of the guild's schedule, every word of it,
+// if it went out today in the guild's timezone, looked up again (usually from
+// the cache).
+func (b *bot) today(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	t, ok := b.targetFor(i.GuildID)
+	if !ok {
+		if len(b.guildConfigs()) > 0 {
+			respondEphemeral(s, i, b.noTargetMessage())
+			return
+		}
+		t = target{loc: b.cfg.location()} // e.g. INTERVAL mode, which has no env schedule
+	}
+	latest := b.hist.Latest(t.key)
+	if len(latest) == 0 || !wotd.SameDay(latest[0].PostedAt.In(t.loc), time.Now().In(t.loc)) {
+		respondEphemeral(s, i, "Today's word hasn't been posted yet.")
+		return
+	}
+	if err := deferReply(s, i, false); err != nil {
+		slog.Error("[today] could not defer reply", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	lang := b.prefsFor(t.key).Lang
+	words := make([]wotd.WordData, 0, len(latest))
+	for _, e := range latest {
+		w, err := wotd.FetchDefinition(ctx, e.Word, lang)
+		if err != nil {
+			slog.Warn("[today] lookup failed", "word", e.Word, "err", err)
+			w = wotd.WordData{Word: e.Word}
+		}
+		words = append(words, w)
+	}
+	replies := wotdResponses(words, b.cfg.PlainText)
+	if err := editReply(s, i, replies[0]); err != nil {
+		slog.Error("[today] could not send reply", "words", len(words), "err", err)
+		return
+	}
+	b.recordPost(kindOnDemand)
+	for _, r := range replies[1:] {
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: r.Content, Embeds: r.Embeds}); err != nil {
+			slog.Error("[today] could not send follow-up", "err", err)
+			return
+		}
+	}
+}
+
+func (b *bot) onDefinePage(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	parts := strings.Split(customID, ":") // define_page:<dir>:<key>:<index>
+	if len(parts) != 4 {
+		return
+	}
+	key := parts[2]
+	idx, err := strconv.Atoi(parts[3])
+	pg, ok := b.pages.Get(key)
+	if err != nil || !ok || idx < 0 || idx >= len(pg.senses) {
+		respondEphemeral(s, i, "⚠️ This lookup has expired, run /define again.")
+		return
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: sensePage(key, pg.word, pg.senses, idx),
+	})
+}
+
+// sensePage renders sense idx with a "Sense n of m" footer and the buttons
+// to move between senses, disabled at either end.
+func sensePage(key string, w wotd.WordData, ss []sense, idx int) *discordgo.InteractionResponseData {
+	sn := ss[idx]
+	lines := []string{wotd.Heading(w), fmt.Sprintf("%s — %s", wotd.POSLabel(sn.pos), sn.def.Definition)}
+	if ex := strings.TrimSpace(sn.def.Example); ex != "" {
+		lines = append(lines, fmt.Sprintf("> *\"%s\"*", ex))
+	}
+	lines = append(lines, fmt.Sprintf("*Sense %d of %d*", idx+1, len(ss)))
+	content := strings.Join(lines, "\n") + wotd.SourceCredit(w)
+	button := func(dir, label string, to int, disabled bool) discordgo.Button {
+		return discordgo.Button{
+			Label:    label,
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("%s:%s:%s:%d", definePageID, dir, key, to),
+			Disabled: disabled,
+		}
+	}
+	return &discordgo.InteractionResponseData{
+		Content: wotd.FitMessage(content),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				button("prev", "◀ Prev", idx-1, idx == 0),
+				button("next", "Next ▶", idx+1, idx == len(ss)-1),
+			}},
+		},
+	}
+}
+
+// ---------------------------
+// /config (per-guild schedule)
+// ---------------------------
+
+func (b *bot) configCommand(guildID string, sub *discordgo.ApplicationCommandInteractionDataOption) string {
+	if guildID == "" {
+		return "⚠️ /config only works in a server."
+	}
+	opts := optionMap(sub.Options)
+	var (
+		msg string
+		err error
+	)
+	switch sub.Name {
+	case "set-channel":
+		channelID := opts["channel"].ChannelValue(nil).ID
+		err = b.store.SetGuildChannel(guildID, channelID)
+		msg = fmt.Sprintf("✅ The Word of the Day will be posted in <#%s>.", channelID)
+	case "set-time":
+		postAt := strings.TrimSpace(opts["time"].StringValue())
+		for _, hm := range splitList(postAt) {
+			if _, ok := wotd.ParseHM(hm); !ok {
+				return fmt.Sprintf("⚠️ %q isn't a valid 24h HH:MM time.", hm)
+			}
+		}
+		if postAt == "" {
+			return "⚠️ Please give at least one HH:MM time."
+		}
+		var gc GuildConfig
+		if gc, err = b.store.GuildConfig(guildID); err != nil {
+			break
+		}
+		tz := gc.TZ
+		if opt, ok := opts["tz"]; ok {
+			tz = strings.TrimSpace(opt.StringValue())
+			if _, err := time.LoadLocation(tz); err != nil || tz == "" {
+				return fmt.Sprintf("⚠️ Unknown timezone %q.", tz)
+			}
+		}
+		err = b.store.SetGuildTime(guildID, tz, postAt)
+		msg = fmt.Sprintf("✅ The Word of the Day will be posted at %s", postAt)
+		if tz != "" {
+			msg += " (" + tz + ")"
+		}
+		msg += "."
+	case "set-language":
+		lang := strings.ToLower(strings.TrimSpace(opts["language"].StringValue()))
+		if !slices.Contains(supportedLangs, lang) {
+			return fmt.Sprintf("⚠️ Unsupported language %q.", lang)
+		}
+		err = b.store.SetGuildLanguage(guildID, lang)
+		msg = fmt.Sprintf("✅ Words will be in %s.", lang)
+	case "set-difficulty":
+		level := strings.ToLower(strings.TrimSpace(opts["difficulty"].StringValue()))
+		if _, perr := wotd.ParseDifficulty(level); perr != nil || level == "" {
+			return fmt.Sprintf("⚠️ Unknown difficulty %q.", level)
+		}
+		err = b.store.SetGuildDifficulty(guildID, level)
+		msg = fmt.Sprintf("✅ Difficulty set to %s.", level)
+	default:
+		return "⚠️ Unknown config option."
+	}
+	if err != nil {
+		slog.Error("[config] save failed", "guild", guildID, "err", err)
+		return "⚠️ Could not save the config, please try again."
+	}
+	b.replan()
+	return msg
+}
+
+func languageChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var out []*discordgo.ApplicationCommandOptionChoice
+	for _, code := range supportedLangs {
+		out = append(out, &discordgo.ApplicationCommandOptionChoice{Name: code, Value: code})
+	}
+	return out
+}
+
+// prefsFor is the word selection for a guild: its /config language and
+// difficulty over the env defaults. "" (the env schedule, DMs) gets the
+// defaults.
+func (b *bot) prefsFor(guildID string) wotd.Prefs {
+	if guildID == "" {
+		return wotd.DefaultPrefs
+	}
+	gc, err := b.store.GuildConfig(guildID)
+	if err != nil {
+		slog.Error("[config] could not load guild config", "guild", guildID, "err", err)
+		return wotd.DefaultPrefs
+	}
+	if gc.Lang == "" && gc.Difficulty == "" {
+		return wotd.DefaultPrefs
+	}
+	lang := wotd.DefaultPrefs.Lang
+	if gc.Lang != "" {
+		lang = gc.Lang
+	}
+	d := wotd.DefaultPrefs.Difficulty
+	if gc.Difficulty != "" {
+		d, _ = wotd.ParseDifficulty(gc.Difficulty) // checked by /config
+	}
+	return wotd.NewPrefs(lang, d, b.cfg.MinLength, b.cfg.MaxLength)
+}
+
+// replan wakes the scheduler to pick up changed guild configs.
+func (b *bot) replan() {
+	select {
+	case b.reload <- struct{}{}:
+	default: // already pending
+	}
+}
+
+// ---------------------------
+// /post (trigger the scheduled post)
+// ---------------------------
+
+// noTargetMessage is the reply when targetFor finds no schedule for a guild:
+// with any guild on /config the env config no longer covers the others.
+func (b *bot) noTargetMessage() string {
+	if len(b.guildConfigs()) > 0 {
+		return "⚠️ This server isn't configured yet; use /config set-channel and /config set-time."
+	}
+	return "⚠️ No channel configured; set CHANNEL_ID or use /config set-channel."
+}
+
+// postNow runs the scheduler's post for this guild's target. Fetching and
+// sending can outlast the interaction deadline, so the reply is deferred.
+// DMs are refused, as DefaultMemberPermissions doesn't apply there.
+func (b *bot) postNow(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "⚠️ /post only works in a server.")
+		return
+	}
+	t, ok := b.targetFor(i.GuildID)
+	if !ok {
+		respondEphemeral(s, i, b.noTargetMessage())
+		return
+	}
+	if err := deferReply(s, i, true); err != nil {
+		slog.Error("[post] could not defer reply", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	msg := "Posted."
+	if _, sent := b.postWOTD(ctx, t); sent == 0 {
+		msg = "⚠️ Could not post to any channel, check the logs."
+	}
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+}
+
+// ---------------------------
+// /setword (pin the next scheduled word)
+// ---------------------------
+
+// setWord answers /setword: the target's next scheduled post uses word,
+// looked up again at post time. The pin is kept even without a definition,
+// but the admin is warned right away. A pin skips the selector, so the
+// blocklist and /feedback are checked here. Only servers can pin a word:
+// DefaultMemberPermissions doesn't apply in DMs.
+func (b *bot) setWord(s *discordgo.Session, i *discordgo.InteractionCreate, word string) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "⚠️ /setword only works in a server.")
+		return
+	}
+	if word == "" {
+		respondEphemeral(s, i, "Please give the word to post.")
+		return
+	}
+	if reported := b.reportedFunc(); !wotd.Allowed(word) || reported != nil && reported(word) {
+		respondEphemeral(s, i, fmt.Sprintf("⚠️ %s is blocked and can't be posted.", word))
+		return
+	}
+	t, ok := b.targetFor(i.GuildID)
+	if !ok {
+		respondEphemeral(s, i, b.noTargetMessage())
+		return
+	}
+	if err := b.state.SetOverride(t.key, word); err != nil {
+		slog.Error("[setword] could not save", "word", word, "err", err)
+		respondEphemeral(s, i, "⚠️ Could not save the word, please try again.")
+		return
+	}
+	slog.Info("[setword] word pinned for the next post", "target", t.key, "word", word)
+	if err := deferReply(s, i, true); err != nil {
+		slog.Error("[setword] could not defer reply", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	msg := fmt.Sprintf("✅ The next scheduled post will be **%s**.", wotd.CapitalizeWord(word))
+	if _, err := wotd.FetchDefinition(ctx, word, b.prefsFor(t.key).Lang); errors.Is(err, wotd.ErrNoDefinition) {
+		msg += fmt.Sprintf("\n⚠️ No definition found for %s; it will be posted without one.", word)
+	} else if err != nil {
+		msg += fmt.Sprintf("\n⚠️ Could not look up %s right now; it will be tried again at post time.", word)
+	}
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+}
+
+// ---------------------------
+// /feedback
+// ---------------------------
+
+// feedback records a report. With FEEDBACK_BLOCK_THRESHOLD set, a word
+// reported by that many users is never picked again.
+func (b *bot) feedback(userID, word, reason string) string {
+	if word == "" {
+		return "Please give the word you're reporting."
+	}
+	if err := b.store.AddFeedback(word, userID, reason, time.Now()); err != nil {
+		slog.Error("[feedback] could not record report", "word", word, "err", err)
+		return "⚠️ Could not record your report, please try again."
+	}
+	slog.Info("[feedback] word reported", "word", word, "user", userID, "reason", reason)
+	if t := b.cfg.FeedbackThreshold; t > 0 {
+		if n, err := b.store.Reporters(word); err == nil && n == t {
+			slog.Warn("[feedback] word reached the report threshold and won't be picked again", "word", word, "reporters", n)
+		}
+	}
+	return "🙏 Thanks, your report was recorded."
+}
+
+// reportedFunc tells the selector which words /feedback has blocked, or is
+// nil when FEEDBACK_BLOCK_THRESHOLD is off.
+func (b *bot) reportedFunc() func(string) bool {
+	t := b.cfg.FeedbackThreshold
+	if t <= 0 {
+		return nil
+	}
+	return func(word string) bool {
+		n, err := b.store.Reporters(word)
+		if err != nil {
+			slog.Error("[feedback] could not count reports", "word", word, "err", err)
+			return false
+		}
+		return n >= t
+	}
+}
+
+// ---------------------------
+// /nextpost
+// ---------------------------
+
+// nextPostMessage previews the guild's next scheduled post the way
+// scheduleDaily plans it, including the POST_JITTER_SECONDS window. DMs get
+// nothing, as DefaultMemberPermissions doesn't apply there.
+func (b *bot) nextPostMessage(guildID string, now time.Time) string {
+	if guildID == "" {
+		return "⚠️ /nextpost only works in a server."
+	}
+	if b.cfg.Interval > 0 {
+		return fmt.Sprintf("⏰ INTERVAL is set, so a word is posted every %s.", b.cfg.Interval)
+	}
+	t, ok := b.targetFor(guildID)
+	if !ok {
+		return "⚠️ Nothing scheduled; set CHANNEL_ID/TZ/POST_AT or use /config."
+	}
+	next := t.sched.Next(now.In(t.loc))
+	if next.IsZero() {
+		return "⚠️ Nothing scheduled in the coming week."
+	}
+	const layout = "Monday, January 2 15:04 MST"
+	msg := fmt.Sprintf("⏰ Next post: %s (in %s)", next.Format(layout), humanDuration(next.Sub(now)))
+	if b.cfg.PostJitter > 0 {
+		following, _ := nextDue(b.targets(), next)
+		if w := wotd.JitterWindow(next, following, b.cfg.PostJitter); w > 0 {
+			msg += fmt.Sprintf("\nWith jitter it may go out as late as %s.", next.Add(w).Format("15:04:05 MST"))
+		}
+	}
+	if b.cfg.DryRun {
+		msg += "\nDRY_RUN is on, so it will only be logged."
+	}
+	return msg
+}
+
+// humanDuration renders d as e.g. "2d 3h", "3h 12m" or "12m", rounded up to
+// the minute so a post a few seconds away doesn't read "in 0m".
+func humanDuration(d time.Duration) string {
+	mins := int((d + time.Minute - 1) / time.Minute)
+	days, hours, mins := mins/(24*60), mins/60%24, mins%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
+
+// ---------------------------
+// /subscribe, /unsubscribe
+// ---------------------------
+
+func (b *bot) subscription(userID string, subscribe bool) string {
+	if subscribe {
+		if err := b.store.AddSubscriber(userID); err != nil {
+			slog.Error("[subscribers] add failed", "user", userID, "err", err)
+			return "⚠️ Could not subscribe you, please try again."
+		}
+		return "📬 Subscribed! You'll get the Word of the Day by DM. Make sure DMs from server members are allowed."
+	}
+	removed, err := b.store.RemoveSubscriber(userID)
+	if err != nil {
+		slog.Error("[subscribers] remove failed", "user", userID, "err", err)
+		return "⚠️ Could not unsubscribe you, please try again."
+	}
+	if !removed {
+		return "You weren't subscribed."
+	}
+	return "📭 Unsubscribed."
+}
+
+// ---------------------------
+// /stats
+// ---------------------------
+
+// recordPost counts a word sent to a channel for /stats: scheduled posts, and
+// replies to /wotd (and its "Another word" button), /random, /today and
+// /define that showed a word.
+func (b *bot) recordPost(kind string) {
+	if err := b.store.RecordPost(kind, time.Now()); err != nil {
+		slog.Error("[stats] could not record post", "err", err)
+	}
+}
+
+func (b *bot) statsMessage() string {
+	now := time.Now().In(b.cfg.location())
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	today, err := b.store.PostsSince(midnight)
+	if err != nil {
+		slog.Error("[stats] could not count posts", "err", err)
+		return "⚠️ Could not load stats right now."
+	}
+	sinceStart, err := b.store.PostsSince(b.started)
+	if err != nil {
+		slog.Error("[stats] could not count posts", "err", err)
+		return "⚠️ Could not load stats right now."
+	}
+	return fmt.Sprintf("📊 Uptime: %s\nWords posted today: %d\nWords posted since start: %d",
+		time.Since(b.started).Round(time.Second), today, sinceStart)
+}
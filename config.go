@@ -0,0 +1,451 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"wotd.go/wotd"
+)
+
+// ---------------------------
+// Config
+// ---------------------------
+
+type Config struct {
+	Token             string
+	GuildIDs          []string // optional; GUILD_ID is comma-separated, empty registers globally
+	ChannelIDs        []string // required for scheduled posting; CHANNEL_ID is comma-separated
+	WebhookURL        string   // post scheduled env posts through this webhook instead of CHANNEL_ID
+	WebhookUsername   string   // name shown on webhook posts; empty = the webhook's own
+	WebhookAvatar     string   // avatar URL shown on webhook posts; empty = the webhook's own
+	TZ                string   // IANA timezone, e.g. "America/New_York"
+	PostAt            string   // HH:MM 24h local in TZ
+	HistoryPath       string   // old history JSON file, imported into the database once
+	HistorySize       int      // how many posted words to remember
+	HTTPTimeout       time.Duration
+	HTTPRetries       int      // attempts per API request before giving up
+	UserAgent         string   // User-Agent on outbound API requests
+	SendConcurrency   int      // channels a scheduled post is sent to at once
+	PlainText         bool     // send plain markdown instead of embeds
+	Providers         []string // definition providers in lookup order
+	RandomWordAPIs    []string // random word APIs in failover order
+	MinLength         int      // random word length bounds; 0 = unbounded
+	MaxLength         int
+	StatePath         string        // old scheduler state JSON file, imported into the database once
+	Catchup           bool          // post immediately on startup if today's post was missed
+	CatchupMaxAge     time.Duration // 0 = catch up on any miss from today
+	SkipWeekends      bool          // only post Monday–Friday in TZ
+	LogLevel          string        // debug, info, warn or error
+	LogFormat         string        // "json" for JSON lines, otherwise text
+	CacheSize         int           // definition cache entries; 0 disables
+	CacheTTL          time.Duration // 0 = cached definitions never expire
+	BreakerThreshold  int           // consecutive provider failures that open its breaker; 0 disables
+	BreakerCooldown   time.Duration // how long an open breaker skips its provider
+	MissWarnPercent   int           // warn when this % of recent lookups had no definition; 0 = never
+	MissWarnWindow    int           // recent candidate lookups the miss rate is taken over
+	HealthPort        string        // port for /healthz and /readyz
+	DBPath            string        // SQLite database for per-guild config, subscribers, post counts and feedback
+	Lang              string        // language code for words and definitions
+	AllPOS            bool          // show every part of speech, not just the first
+	BlocklistPath     string        // optional file of words never to post, one per line
+	Difficulty        string        // easy, medium or hard; empty = any word
+	WOTDRetries       int           // random words to try for one with a definition
+	WordSource        string        // "random" (API) or "file"
+	WordlistPath      string        // word list for the file source
+	WordlistShuffle   bool          // shuffle the word list on every pass
+	DryRun            bool          // log scheduled posts instead of sending them
+	Emoji             string        // leading emoji of the message header
+	Header            string        // header text; set but empty hides the header
+	AllowNonAlpha     bool          // accept random words with hyphens, apostrophes, digits
+	ShowDate          bool          // stamp scheduled posts with the date in TZ
+	DateLayout        string        // Go time layout of the SHOW_DATE stamp
+	PostFooter        string        // standing footer under scheduled posts; may span lines
+	MultiEmbed        bool          // one embed per part of speech in single-word posts
+	RequireDefinition bool          // skip a post rather than send a word without a definition
+	CleanupCommands   bool          // delete registered commands on shutdown
+	DigestAt          string        // weekly recap time, e.g. "SUN 18:00" in TZ
+	RenderCard        bool          // attach a PNG card with the word to scheduled posts
+	PostJitter        time.Duration // random delay added to each scheduled post
+	Fields            []string      // message fields to show; empty = all
+	Interval          time.Duration // post on a fixed interval instead of POST_AT (testing)
+	FeedbackThreshold int           // block words reported by this many users; 0 = never
+	MinDefLength      int           // re-roll words whose definition is shorter; 0 = any
+	EnvFile           string        // env file that was loaded, if any
+	SpoilerDefinition bool          // hide scheduled definitions behind spoiler markup
+	EmbedColor        int           // embed color as 0xRRGGBB
+	ThreadName        string        // post into a thread of this name under each channel
+	Deterministic     bool          // same word list entry everywhere on a given date
+	POSEmoji          bool          // lead parts of speech with an emoji
+	Environment       string        // "prod", or "dev" to post only to TestChannelID
+	TestChannelID     string        // the one channel scheduled posts go to in dev
+	WordsPerPost      int           // words in each scheduled post
+	WOTDCooldown      time.Duration // per-user wait between /wotd uses; 0 = none
+	envProblems       []error       // values that couldn't be read; reported by Validate
+	envWarnings       []string      // cosmetic values that fell back to their default; logged by main
+}
+
+// loadConfig reads the environment. It logs nothing, since logging isn't set
+// up until LOG_LEVEL/LOG_FORMAT are known: values it can't read are kept for
+// Validate to report, except cosmetic ones with a safe default, which are
+// kept as warnings.
+func loadConfig() Config {
+	envFile := loadEnvFile()
+	var bad []error
+	var warnings []string
+	cfg := Config{
+		Token:             loadToken(&bad),
+		GuildIDs:          splitList(os.Getenv("GUILD_ID")),
+		ChannelIDs:        splitList(os.Getenv("CHANNEL_ID")),
+		WebhookURL:        strings.TrimSpace(os.Getenv("WEBHOOK_URL")),
+		WebhookUsername:   os.Getenv("WEBHOOK_USERNAME"),
+		WebhookAvatar:     os.Getenv("WEBHOOK_AVATAR"),
+		TZ:                os.Getenv("TZ"),
+		PostAt:            os.Getenv("POST_AT"),
+		HistoryPath:       envOr("WOTD_HISTORY_PATH", "history.json"),
+		HistorySize:       envInt(&bad, "WOTD_HISTORY_SIZE", 30),
+		HTTPTimeout:       time.Duration(envInt(&bad, "HTTP_TIMEOUT_SECONDS", 10)) * time.Second,
+		HTTPRetries:       envInt(&bad, "HTTP_RETRIES", 3),
+		UserAgent:         envOr("HTTP_USER_AGENT", wotd.DefaultUserAgent),
+		SendConcurrency:   envInt(&bad, "SEND_CONCURRENCY", 4),
+		PlainText:         envBool("PLAIN_TEXT"),
+		Providers:         splitList(envOr("DEFINITION_PROVIDERS", "dictionaryapi,wiktionary")),
+		RandomWordAPIs:    splitList(envOr("RANDOM_WORD_APIS", "heroku,vercel")),
+		MinLength:         envInt(&bad, "WORD_MIN_LENGTH", 0),
+		MaxLength:         envInt(&bad, "WORD_MAX_LENGTH", 0),
+		StatePath:         envOr("STATE_PATH", "state.json"),
+		Catchup:           os.Getenv("CATCHUP") != "0",
+		CatchupMaxAge:     envDuration(&bad, "CATCHUP_MAX_AGE", 0),
+		SkipWeekends:      envBool("SKIP_WEEKENDS"),
+		LogLevel:          envOr("LOG_LEVEL", "info"),
+		LogFormat:         os.Getenv("LOG_FORMAT"),
+		CacheSize:         envInt(&bad, "DEF_CACHE_SIZE", 500),
+		CacheTTL:          envDuration(&bad, "DEF_CACHE_TTL", 0),
+		BreakerThreshold:  envInt(&bad, "BREAKER_THRESHOLD", 5),
+		BreakerCooldown:   envDuration(&bad, "BREAKER_COOLDOWN", time.Minute),
+		MissWarnPercent:   envInt(&bad, "NO_DEF_WARN_PERCENT", 0),
+		MissWarnWindow:    envInt(&bad, "NO_DEF_WARN_WINDOW", 50),
+		HealthPort:        envOr("HEALTH_PORT", "8080"),
+		DBPath:            envOr("DB_PATH", "wotd.db"),
+		Lang:              langCode(os.Getenv("LANG")),
+		AllPOS:            envBool("WOTD_ALL_POS"),
+		BlocklistPath:     os.Getenv("BLOCKLIST_PATH"),
+		Difficulty:        os.Getenv("DIFFICULTY"),
+		WOTDRetries:       envInt(&bad, "WOTD_RETRIES", 5),
+		WordSource:        strings.ToLower(envOr("WORD_SOURCE", "random")),
+		WordlistPath:      os.Getenv("WORDLIST_PATH"),
+		WordlistShuffle:   envBool("WORDLIST_SHUFFLE"),
+		DryRun:            envBool("DRY_RUN"),
+		Emoji:             envOrEmpty("WOTD_EMOJI", "📖"),
+		Header:            envOrEmpty("WOTD_HEADER", "Word of the Day"),
+		AllowNonAlpha:     envBool("ALLOW_NON_ALPHA"),
+		ShowDate:          envBool("SHOW_DATE"),
+		DateLayout:        envOr("DATE_LAYOUT", defaultDateLayout),
+		MultiEmbed:        envBool("MULTI_EMBED"),
+		PostFooter:        strings.ReplaceAll(strings.TrimSpace(os.Getenv("POST_FOOTER")), `\n`, "\n"),
+		RequireDefinition: envBool("REQUIRE_DEFINITION"),
+		CleanupCommands:   envBool("CLEANUP_COMMANDS"),
+		DigestAt:          os.Getenv("DIGEST_AT"),
+		RenderCard:        envBool("RENDER_CARD"),
+		PostJitter:        time.Duration(envInt(&bad, "POST_JITTER_SECONDS", 0)) * time.Second,
+		Fields:            splitList(os.Getenv("WOTD_FIELDS")),
+		Interval:          envDuration(&bad, "INTERVAL", 0),
+		FeedbackThreshold: envInt(&bad, "FEEDBACK_BLOCK_THRESHOLD", 0),
+		MinDefLength:      envInt(&bad, "MIN_DEF_LENGTH", 0),
+		EnvFile:           envFile,
+		SpoilerDefinition: envBool("SPOILER_DEFINITION"),
+		EmbedColor:        envColor(&warnings, "EMBED_COLOR", wotd.DefaultEmbedColor),
+		ThreadName:        os.Getenv("THREAD_NAME"),
+		Deterministic:     envBool("DETERMINISTIC"),
+		POSEmoji:          envBool("POS_EMOJI"),
+		Environment:       strings.ToLower(envOr("ENVIRONMENT", "prod")),
+		TestChannelID:     os.Getenv("TEST_CHANNEL_ID"),
+		WordsPerPost:      envInt(&bad, "WORDS_PER_POST", 1),
+		WOTDCooldown:      time.Duration(envInt(&bad, "WOTD_COOLDOWN_SECONDS", 0)) * time.Second,
+	}
+	cfg.envProblems, cfg.envWarnings = bad, warnings
+	return cfg
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envOrEmpty is envOr where setting the variable to "" counts as a value.
+func envOrEmpty(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+// setupLogging installs the default slog logger from LOG_LEVEL/LOG_FORMAT.
+func setupLogging(cfg Config) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if strings.EqualFold(cfg.LogFormat, "json") {
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(h))
+}
+
+// fatal logs at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// loadToken reads the bot token from DISCORD_TOKEN_FILE (e.g. a mounted
+// Docker or Kubernetes secret) if set, else takes DISCORD_TOKEN.
+func loadToken(bad *[]error) string {
+	path := os.Getenv("DISCORD_TOKEN_FILE")
+	if path == "" {
+		return os.Getenv("DISCORD_TOKEN")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		*bad = append(*bad, fmt.Errorf("cannot read DISCORD_TOKEN_FILE %q: %v", path, err))
+		return ""
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		*bad = append(*bad, fmt.Errorf("DISCORD_TOKEN_FILE %q is empty", path))
+	}
+	return token
+}
+
+// loadEnvFile loads ENV_FILE if set, else ./.env if present, and returns
+// the path it loaded or "" if none. Variables already in the process
+// environment win over the file.
+func loadEnvFile() string {
+	if path := os.Getenv("ENV_FILE"); path != "" {
+		if err := godotenv.Load(path); err != nil {
+			return "" // reported by Validate
+		}
+		return path
+	}
+	if err := godotenv.Load(); err != nil {
+		return "" // ok if .env missing
+	}
+	return ".env"
+}
+
+// SHOW_DATE stamp unless DATE_LAYOUT says otherwise, e.g. "Monday, June 3".
+const defaultDateLayout = "Monday, January 2"
+
+// dateLayoutProblem explains why DATE_LAYOUT looks wrong, or returns "". A
+// layout that renders two different days the same (no day field, or no
+// recognized fields at all, e.g. a strftime "%d.%m.%Y") would stamp every
+// post alike.
+func (c Config) dateLayoutProblem() string {
+	a := time.Date(2024, time.March, 7, 9, 0, 0, 0, time.UTC)
+	if a.Format(c.DateLayout) == a.AddDate(0, 0, 1).Format(c.DateLayout) {
+		return "renders different days the same; use Go's reference date, e.g. 2.1.2006 for 7.3.2024"
+	}
+	return ""
+}
+
+// Validate reports every problem with the config at once so a bad .env can
+// be fixed in one go instead of surfacing later inside the scheduler.
+func (c Config) Validate() error {
+	problems := slices.Clone(c.envProblems)
+	if path := os.Getenv("ENV_FILE"); path != "" && c.EnvFile == "" {
+		problems = append(problems, fmt.Errorf("ENV_FILE %q could not be loaded", path))
+	}
+	if c.Token == "" && os.Getenv("DISCORD_TOKEN_FILE") == "" {
+		problems = append(problems, errors.New("DISCORD_TOKEN or DISCORD_TOKEN_FILE is required"))
+	}
+	if c.HTTPTimeout <= 0 {
+		problems = append(problems, fmt.Errorf("HTTP_TIMEOUT_SECONDS %d must be more than 0", int(c.HTTPTimeout/time.Second)))
+	}
+	if c.TZ != "" {
+		if _, err := time.LoadLocation(c.TZ); err != nil {
+			problems = append(problems, fmt.Errorf("TZ %q is not a valid IANA timezone: %v", c.TZ, err))
+		}
+	}
+	for _, hm := range splitList(c.PostAt) {
+		if _, ok := wotd.ParseHM(hm); !ok {
+			problems = append(problems, fmt.Errorf("POST_AT entry %q is not a 24h HH:MM time", hm))
+		}
+	}
+	if c.MinLength > 0 && c.MaxLength > 0 && c.MinLength > c.MaxLength {
+		problems = append(problems, fmt.Errorf("WORD_MIN_LENGTH %d can't be more than WORD_MAX_LENGTH %d", c.MinLength, c.MaxLength))
+	}
+	if !slices.Contains(supportedLangs, c.Lang) {
+		problems = append(problems, fmt.Errorf("LANG %q must be one of %s", c.Lang, strings.Join(supportedLangs, ", ")))
+	}
+	if _, ok := parseWeeklyAt(c.DigestAt); c.DigestAt != "" && !ok {
+		problems = append(problems, fmt.Errorf("DIGEST_AT %q is not a weekly DAY HH:MM time such as SUN 18:00", c.DigestAt))
+	}
+	if _, err := wotd.ParseDifficulty(c.Difficulty); err != nil {
+		problems = append(problems, err)
+	}
+	if c.WOTDRetries < 1 {
+		problems = append(problems, fmt.Errorf("WOTD_RETRIES %d must be at least 1", c.WOTDRetries))
+	}
+	if c.WordsPerPost < 1 {
+		problems = append(problems, fmt.Errorf("WORDS_PER_POST %d must be at least 1", c.WordsPerPost))
+	}
+	if c.SendConcurrency < 1 {
+		problems = append(problems, fmt.Errorf("SEND_CONCURRENCY %d must be at least 1", c.SendConcurrency))
+	}
+	switch c.WordSource {
+	case "random":
+	case "file":
+		if c.WordlistPath == "" {
+			problems = append(problems, errors.New("WORDLIST_PATH is required when WORD_SOURCE=file"))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("WORD_SOURCE %q must be random or file", c.WordSource))
+	}
+	if c.Deterministic && c.WordSource != "file" {
+		problems = append(problems, errors.New("DETERMINISTIC needs WORD_SOURCE=file; random API words can't be reproduced"))
+	}
+	switch c.Environment {
+	case "prod":
+		if (c.TZ != "" || c.PostAt != "") && len(c.ChannelIDs) == 0 && c.WebhookURL == "" {
+			problems = append(problems, errors.New("CHANNEL_ID or WEBHOOK_URL is required when TZ or POST_AT is set"))
+		}
+	case "dev":
+		if c.TestChannelID == "" {
+			problems = append(problems, errors.New("TEST_CHANNEL_ID is required when ENVIRONMENT=dev"))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("ENVIRONMENT %q must be dev or prod", c.Environment))
+	}
+	if c.WebhookURL != "" {
+		if _, _, err := parseWebhookURL(c.WebhookURL); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if c.Interval != 0 && c.PostAt != "" {
+		problems = append(problems, errors.New("POST_AT and INTERVAL can't both be set"))
+	}
+	if c.Interval < 0 {
+		problems = append(problems, fmt.Errorf("INTERVAL %s must be positive", c.Interval))
+	}
+	if c.BreakerThreshold > 0 && c.BreakerCooldown <= 0 {
+		problems = append(problems, fmt.Errorf("BREAKER_COOLDOWN %s must be positive", c.BreakerCooldown))
+	}
+	if c.MissWarnPercent < 0 || c.MissWarnPercent > 100 {
+		problems = append(problems, fmt.Errorf("NO_DEF_WARN_PERCENT %d must be between 0 and 100", c.MissWarnPercent))
+	}
+	if c.MissWarnPercent > 0 && c.MissWarnWindow < 1 {
+		problems = append(problems, fmt.Errorf("NO_DEF_WARN_WINDOW %d must be at least 1", c.MissWarnWindow))
+	}
+	if c.CatchupMaxAge < 0 {
+		problems = append(problems, fmt.Errorf("CATCHUP_MAX_AGE %s must not be negative", c.CatchupMaxAge))
+	}
+	return errors.Join(problems...)
+}
+
+// dev reports whether this is a development run (ENVIRONMENT=dev).
+func (c Config) dev() bool {
+	return c.Environment == "dev"
+}
+
+// location returns the configured TZ, or the local zone if unset or invalid.
+func (c Config) location() *time.Location {
+	if loc, err := time.LoadLocation(c.TZ); err == nil && c.TZ != "" {
+		return loc
+	}
+	return time.Local
+}
+
+// Languages the random word API serves; LANG and /config set-language accept
+// these.
+var supportedLangs = []string{"en", "es", "it", "de", "fr", "zh", "pt-br"}
+
+// langCode reduces LANG to a language code, so a system locale such as
+// "de_DE.UTF-8" means "de". The region is kept only where it is part of a
+// supported code ("pt_BR" → "pt-br"). Unset, "C" and "POSIX" mean English.
+func langCode(v string) string {
+	code, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(v)), ".")
+	code, _, _ = strings.Cut(code, "@")
+	code = strings.ReplaceAll(code, "_", "-")
+	if code == "" || code == "c" || code == "posix" {
+		return "en"
+	}
+	if slices.Contains(supportedLangs, code) {
+		return code
+	}
+	code, _, _ = strings.Cut(code, "-")
+	return code
+}
+
+// splitList parses a comma-separated env value, dropping empty entries.
+func splitList(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// envBool treats "1", "true", "yes" (any case) as set.
+func envBool(key string) bool {
+	switch strings.ToLower(os.Getenv(key)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// envDuration and envInt return def for an unset variable, and also for one
+// they can't parse, adding the problem to bad.
+func envDuration(bad *[]error, key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		*bad = append(*bad, fmt.Errorf("%s %q is not a duration such as 90s or 1h30m", key, v))
+		return def
+	}
+	return d
+}
+
+// envColor parses a hex color such as "#5865F2" (the # is optional). A bad
+// value falls back to def with a warning added to warnings.
+func envColor(warnings *[]string, key string, def int) int {
+	v := strings.TrimPrefix(strings.TrimSpace(os.Getenv(key)), "#")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(v, 16, 32)
+	if err != nil || len(v) != 6 {
+		*warnings = append(*warnings, fmt.Sprintf("%s %q is not a hex color such as #5865F2, using the default #%06X", key, os.Getenv(key), def))
+		return def
+	}
+	return int(n)
+}
+
+func envInt(bad *[]error, key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		*bad = append(*bad, fmt.Errorf("%s %q is not a whole number", key, v))
+		return def
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"wotd.go/wotd"
+)
+
+func TestLangCode(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", "en"},
+		{"C", "en"},
+		{"POSIX", "en"},
+		{"en_US.UTF-8", "en"},
+		{"de_DE.UTF-8", "de"},
+		{"fr_FR@euro", "fr"},
+		{"pt_BR.UTF-8", "pt-br"},
+		{"pt-BR", "pt-br"},
+		{"es", "es"},
+	}
+	for _, tt := range tests {
+		if got := langCode(tt.in); got != tt.want {
+			t.Errorf("langCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateReportsUnreadableValues(t *testing.T) {
+	t.Setenv("DISCORD_TOKEN", "token")
+	t.Setenv("WOTD_RETRIES", "five")
+	t.Setenv("CATCHUP_MAX_AGE", "2 hours")
+	err := loadConfig().Validate()
+	if err == nil {
+		t.Fatal("Validate = nil, want the unreadable values reported")
+	}
+	for _, key := range []string{"WOTD_RETRIES", "CATCHUP_MAX_AGE"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Validate = %v, want %s reported", err, key)
+		}
+	}
+}
+
+func TestBadEmbedColorOnlyWarns(t *testing.T) {
+	t.Setenv("DISCORD_TOKEN", "token")
+	t.Setenv("EMBED_COLOR", "blurple")
+	cfg := loadConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate = %v, want a bad EMBED_COLOR to fall back instead", err)
+	}
+	if len(cfg.envWarnings) != 1 || cfg.EmbedColor != wotd.DefaultEmbedColor {
+		t.Errorf("warnings = %q, color = %#x; want one warning and the default color", cfg.envWarnings, cfg.EmbedColor)
+	}
+}
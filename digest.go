@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"wotd.go/wotd"
+)
+
+// ---------------------------
+// Weekly digest
+// ---------------------------
+
+// weeklyAt is a weekly post time such as "SUN 18:00".
+type weeklyAt struct {
+	day time.Weekday
+	pt  wotd.PostTime
+}
+
+var weekdays = map[string]time.Weekday{
+	"SUN": time.Sunday, "MON": time.Monday, "TUE": time.Tuesday, "WED": time.Wednesday,
+	"THU": time.Thursday, "FRI": time.Friday, "SAT": time.Saturday,
+}
+
+// parseWeeklyAt parses "DAY HH:MM" with a three-letter day (any case).
+func parseWeeklyAt(v string) (weeklyAt, bool) {
+	day, hm, ok := strings.Cut(strings.TrimSpace(v), " ")
+	if !ok {
+		return weeklyAt{}, false
+	}
+	wd, ok := weekdays[strings.ToUpper(day)]
+	if !ok {
+		return weeklyAt{}, false
+	}
+	pt, ok := wotd.ParseHM(strings.TrimSpace(hm))
+	if !ok {
+		return weeklyAt{}, false
+	}
+	return weeklyAt{day: wd, pt: pt}, true
+}
+
+// nextRun returns the next digest time strictly after now, in now's location.
+func (wa weeklyAt) nextRun(now time.Time) time.Time {
+	for days := 0; days <= 7; days++ {
+		if t := wotd.At(now, days, wa.pt); t.Weekday() == wa.day && t.After(now) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// scheduleDigest posts the weekly recap at DIGEST_AT in TZ until ctx is
+// cancelled, alongside scheduleDaily.
+func (b *bot) scheduleDigest(ctx context.Context, wg *sync.WaitGroup) {
+	wa, ok := parseWeeklyAt(b.cfg.DigestAt)
+	if !ok {
+		return // unset; Validate rejects bad values
+	}
+	loc := b.cfg.location()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			next := wa.nextRun(time.Now().In(loc))
+			slog.Debug("[digest] next digest", "at", next.Format(time.RFC1123))
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				slog.Info("[digest] stopping")
+				return
+			case <-timer.C:
+			}
+			b.postDigest(ctx, next.AddDate(0, 0, -7))
+		}
+	}()
+}
+
+// postDigest sends each target the words it posted since to its channels,
+// skipping targets with a quiet week.
+func (b *bot) postDigest(ctx context.Context, since time.Time) {
+	for _, t := range b.targets() {
+		entries := b.hist.Since(t.key, since)
+		if len(entries) == 0 {
+			slog.Info("[digest] no words this week, skipping", "target", t.key)
+			continue
+		}
+		msg := b.digestMessage(ctx, entries, b.prefsFor(t.key).Lang)
+		for _, channelID := range t.channels {
+			if b.cfg.DryRun {
+				slog.Info("[dry-run] would post digest", "channel", channelID, "message", msg)
+				continue
+			}
+			err := retryRateLimited(channelID, func() error {
+				return b.posterFor(channelID).Post(channelID, &discordgo.MessageSend{Content: msg})
+			})
+			if err != nil {
+				slog.Error("[digest] send failed", "channel", channelID, "err", err)
+			}
+		}
+	}
+}
+
+// digestMessage lists each word with its primary definition in lang.
+func (b *bot) digestMessage(ctx context.Context, entries []HistoryEntry, lang string) string {
+	lines := []string{"🗓️ This week's words:"}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if seen[e.Word] {
+			continue
+		}
+		seen[e.Word] = true
+		line := fmt.Sprintf("• **%s**", wotd.CapitalizeWord(e.Word))
+		if w, err := wotd.FetchDefinition(ctx, e.Word, lang); err == nil {
+			if m, d, ok := w.Primary(); ok {
+				line += fmt.Sprintf(" %s — %s", wotd.POSLabel(m.PartOfSpeech), d.Definition)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return wotd.FitMessage(strings.Join(lines, "\n"))
+}
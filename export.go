@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"wotd.go/wotd"
+)
+
+// ---------------------------
+// /export (history as flashcards)
+// ---------------------------
+
+// exportHistory writes every history entry as word, definition, example.
+// Anki's importer takes the tab-separated form without a header row.
+func exportHistory(ctx context.Context, entries []HistoryEntry, lang string, anki bool) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if anki {
+		cw.Comma = '\t'
+	} else if err := cw.Write([]string{"word", "definition", "example"}); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if seen[e.Word] {
+			continue
+		}
+		seen[e.Word] = true
+		var def, ex string
+		if w, err := wotd.FetchDefinition(ctx, e.Word, lang); err == nil {
+			if m, d, ok := w.Primary(); ok {
+				def, ex = d.Definition, wotd.FirstExample(m)
+			}
+		}
+		if err := cw.Write([]string{e.Word, def, ex}); err != nil {
+			return nil, err
+		}
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
+
+// exportCommand sends the words posted to the requester's server, defined in
+// its language, only to the requester.
+func (b *bot) exportCommand(s *discordgo.Session, i *discordgo.InteractionCreate, format string) {
+	key := b.historyKey(i.GuildID)
+	entries := b.hist.Since(key, time.Time{}) // oldest first reads better as a study deck
+	if len(entries) == 0 {
+		respondEphemeral(s, i, "No words posted yet.")
+		return
+	}
+	if err := deferReply(s, i, true); err != nil {
+		slog.Error("[export] could not defer reply", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	anki := format == "anki"
+	data, err := exportHistory(ctx, entries, b.prefsFor(key).Lang, anki)
+	if err != nil {
+		slog.Error("[export] could not build file", "err", err)
+		msg := "⚠️ Could not export the history, please try again."
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+		return
+	}
+	name, mime := "wotd-history.csv", "text/csv"
+	if anki {
+		name, mime = "wotd-history.txt", "text/tab-separated-values"
+	}
+	msg := "📤 Here are the posted words."
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &msg,
+		Files:   []*discordgo.File{{Name: name, ContentType: mime, Reader: bytes.NewReader(data)}},
+	})
+	if err != nil {
+		slog.Error("[export] could not send file", "err", err)
+	}
+}
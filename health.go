@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ---------------------------
+// Health checks
+// ---------------------------
+
+// health tracks what the liveness/readiness probes report.
+type health struct {
+	connected atomic.Bool // gateway connection is up
+	ready     atomic.Bool // slash commands are registered
+}
+
+// track keeps connected in sync with the session's gateway events.
+func (h *health) track(s *discordgo.Session) {
+	s.AddHandler(func(*discordgo.Session, *discordgo.Connect) { h.connected.Store(true) })
+	s.AddHandler(func(*discordgo.Session, *discordgo.Disconnect) { h.connected.Store(false) })
+}
+
+func probe(ok *atomic.Bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if !ok.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}
+}
+
+// serveHealth starts the probe server on port, serving /healthz, /readyz
+// and Prometheus /metrics.
+// It is shut down when ctx is cancelled; wg tracks it like the scheduler.
+func serveHealth(ctx context.Context, wg *sync.WaitGroup, port string, h *health) {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", probe(&h.connected))
+	mux.Handle("/readyz", probe(&h.ready))
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: ":" + port, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		slog.Info("[health] listening", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("[health] server failed", "err", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("[health] shutdown failed", "err", err)
+		}
+	}()
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------
+// Word history (SQLite store)
+// ---------------------------
+
+type HistoryEntry struct {
+	Word     string    `json:"word"`
+	PostedAt time.Time `json:"posted_at"`
+	Target   string    `json:"target,omitempty"` // state key of the schedule that posted it
+}
+
+// History keeps the last N posted words so the scheduler doesn't repeat itself.
+// They live in the store and are cached here, since the selector checks every
+// candidate. The scheduler and slash commands share it, so access goes
+// through mu.
+type History struct {
+	mu      sync.RWMutex
+	st      *Store
+	size    int
+	entries []HistoryEntry
+}
+
+func loadHistory(st *Store, size int) (*History, error) {
+	h := &History{st: st, size: size}
+	entries, err := st.HistoryEntries(h.keep())
+	if err != nil {
+		return h, err
+	}
+	h.entries = entries
+	return h, nil
+}
+
+// keep is the size as a row limit; SQLite takes -1 for no limit.
+func (h *History) keep() int {
+	if h.size > 0 {
+		return h.size
+	}
+	return -1
+}
+
+// importHistoryFile moves the words of a history JSON file, from before
+// history lived in the store, into st and renames the file so that happens
+// only once. A missing file is nothing to import.
+func importHistoryFile(st *Store, path string, size int) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries []HistoryEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+	if err := st.AddHistory((&History{size: size}).keep(), entries...); err != nil {
+		return err
+	}
+	return os.Rename(path, path+".imported")
+}
+
+func (h *History) Contains(word string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, e := range h.entries {
+		if strings.EqualFold(e.Word, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matching returns up to limit distinct words of the target's starting with
+// prefix (case-insensitive), most recently posted first.
+func (h *History) Matching(target, prefix string, limit int) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	prefix = strings.ToLower(prefix)
+	seen := map[string]bool{}
+	var out []string
+	for i := len(h.entries) - 1; i >= 0 && len(out) < limit; i-- {
+		if h.entries[i].Target != target {
+			continue
+		}
+		w := strings.ToLower(h.entries[i].Word)
+		if seen[w] || !strings.HasPrefix(w, prefix) {
+			continue
+		}
+		seen[w] = true
+		out = append(out, w)
+	}
+	return out
+}
+
+// RecentWords returns up to n of the target's entries, most recently posted
+// first.
+func (h *History) RecentWords(target string, n int) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var out []HistoryEntry
+	for i := len(h.entries) - 1; i >= 0 && len(out) < n; i-- {
+		if h.entries[i].Target == target {
+			out = append(out, h.entries[i])
+		}
+	}
+	return out
+}
+
+// Latest returns the words of the most recent post by the target's
+// schedule, in posting order: every entry it recorded at that post's time,
+// since one post can carry several words (WORDS_PER_POST).
+func (h *History) Latest(target string) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var out []HistoryEntry
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		e := h.entries[i]
+		if e.Target != target {
+			continue
+		}
+		if len(out) > 0 && !e.PostedAt.Equal(out[0].PostedAt) {
+			break
+		}
+		out = append(out, e)
+	}
+	slices.Reverse(out)
+	return out
+}
+
+// Since returns the target's entries posted at or after t, oldest first.
+func (h *History) Since(target string, t time.Time) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var out []HistoryEntry
+	for _, e := range h.entries {
+		if e.Target == target && !e.PostedAt.Before(t) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// AddWord records a word posted by target's schedule, trims to size and
+// saves it to the store.
+func (h *History) AddWord(word, target string, at time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e := HistoryEntry{Word: strings.ToLower(word), PostedAt: at, Target: target}
+	h.entries = append(h.entries, e)
+	if h.size > 0 && len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	return h.st.AddHistory(h.keep(), e)
+}
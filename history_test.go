@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run with -race: the scheduler adds words while commands read history.
+func TestHistoryConcurrentAccess(t *testing.T) {
+	h, err := loadHistory(testStore(t), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const writers, perWriter = 4, 25
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				if err := h.AddWord(fmt.Sprintf("word%d-%d", w, i), "", time.Now()); err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				h.Contains("word0-0")
+				h.RecentWords("", 10)
+				h.Matching("", "word", maxChoices)
+				h.Since("", time.Time{})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(h.RecentWords("", 100)); got != 50 {
+		t.Errorf("kept %d entries, want 50 (size limit)", got)
+	}
+	reloaded, err := loadHistory(h.st, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reloaded.RecentWords("", 100)); got != 50 {
+		t.Errorf("store has %d entries, want 50", got)
+	}
+}
+
+func TestHistoryLatestPerTarget(t *testing.T) {
+	h, err := loadHistory(testStore(t), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	earlier, now := time.Now().Add(-time.Hour), time.Now()
+	for _, e := range []HistoryEntry{
+		{"alpha", earlier, ""}, {"bravo", now, "guild1"}, {"charlie", now, ""}, {"delta", now, ""},
+	} {
+		if err := h.AddWord(e.Word, e.Target, e.PostedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	words := func(entries []HistoryEntry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Word)
+		}
+		return out
+	}
+	if got := words(h.Latest("guild1")); !slices.Equal(got, []string{"bravo"}) {
+		t.Errorf("Latest(guild1) = %q, want bravo", got)
+	}
+	if got := words(h.Latest("")); !slices.Equal(got, []string{"charlie", "delta"}) {
+		t.Errorf("Latest(env) = %q, want both words of the last post", got)
+	}
+	if got := h.Latest("guild2"); len(got) != 0 {
+		t.Errorf("Latest(guild2) = %q, want none of the words other targets posted", words(got))
+	}
+}
+
+func TestHistoryQueriesPerTarget(t *testing.T) {
+	h, err := loadHistory(testStore(t), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weekAgo, now := time.Now().AddDate(0, 0, -7), time.Now()
+	for _, e := range []HistoryEntry{
+		{"alpha", weekAgo.Add(-time.Hour), "guild1"}, {"bravo", now, "guild1"}, {"charlie", now, "guild2"},
+	} {
+		if err := h.AddWord(e.Word, e.Target, e.PostedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := h.Since("guild1", weekAgo)
+	if len(got) != 1 || got[0].Word != "bravo" {
+		t.Errorf("Since(guild1, a week ago) = %v, want only bravo", got)
+	}
+	got = h.RecentWords("guild2", 10)
+	if len(got) != 1 || got[0].Word != "charlie" {
+		t.Errorf("RecentWords(guild2, 10) = %v, want only charlie", got)
+	}
+	if words := h.Matching("guild2", "", maxChoices); !slices.Equal(words, []string{"charlie"}) {
+		t.Errorf("Matching(guild2) = %q, want only charlie", words)
+	}
+}
+
+func TestImportLegacyFiles(t *testing.T) {
+	dir, store := t.TempDir(), testStore(t)
+	posted := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	histPath, statePath := filepath.Join(dir, "history.json"), filepath.Join(dir, "state.json")
+	files := map[string]string{
+		histPath:  `[{"word":"alpha","posted_at":"2024-06-02T09:00:00Z"},{"word":"bravo","posted_at":"2024-06-03T09:00:00Z","target":"guild1"}]`,
+		statePath: `{"last_post":"2024-06-03T09:00:00Z","guild_posts":{"guild1":"2024-06-03T09:00:00Z"},"overrides":{"guild1":"charlie"}}`,
+	}
+	for path, body := range files {
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := importHistoryFile(store, histPath, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := importStateFile(store, statePath); err != nil {
+		t.Fatal(err)
+	}
+	for path := range files {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s still in place after import, it would be imported again", path)
+		}
+	}
+
+	h, err := loadHistory(store, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Matching("guild1", "", maxChoices); !slices.Equal(got, []string{"bravo"}) {
+		t.Errorf("Matching(guild1) = %q, want the imported bravo", got)
+	}
+	if !h.Contains("alpha") {
+		t.Error("imported history lacks alpha")
+	}
+	s, err := loadState(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.LastPost(""); !got.Equal(posted) {
+		t.Errorf("LastPost(env) = %v, want %v", got, posted)
+	}
+	if got := s.LastPost("guild1"); !got.Equal(posted) {
+		t.Errorf("LastPost(guild1) = %v, want %v", got, posted)
+	}
+	if got := s.Override("guild1"); got != "charlie" {
+		t.Errorf("Override(guild1) = %q, want charlie", got)
+	}
+}
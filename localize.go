@@ -0,0 +1,68 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// ---------------------------
+// Command localizations
+// ---------------------------
+
+// localized is a translated command or option name and description. Names
+// must stay lowercase with no spaces, as Discord requires.
+type localized struct{ name, description string }
+
+// Translations keyed by command name, or "command.option" for options.
+// Discord shows them to users whose client uses that locale; the bot still
+// receives the English names, so routing is unaffected.
+var commandLocalizations = map[string]map[discordgo.Locale]localized{
+	"wotd": {
+		discordgo.SpanishES:    {"palabra", "Obtén una palabra del día al azar"},
+		discordgo.SpanishLATAM: {"palabra", "Obtén una palabra del día al azar"},
+		discordgo.French:       {"mot-du-jour", "Obtenir un mot du jour au hasard"},
+		discordgo.German:       {"wort-des-tages", "Ein zufälliges Wort des Tages erhalten"},
+	},
+	"wotd.private": {
+		discordgo.SpanishES:    {"privado", "Mostrar la palabra solo a ti"},
+		discordgo.SpanishLATAM: {"privado", "Mostrar la palabra solo a ti"},
+		discordgo.French:       {"privé", "N'afficher le mot que pour vous"},
+		discordgo.German:       {"privat", "Das Wort nur dir anzeigen"},
+	},
+	"define": {
+		discordgo.SpanishES:    {"definir", "Busca la definición de una palabra"},
+		discordgo.SpanishLATAM: {"definir", "Busca la definición de una palabra"},
+		discordgo.French:       {"définir", "Chercher la définition d'un mot"},
+		discordgo.German:       {"definieren", "Die Definition eines Wortes nachschlagen"},
+	},
+	"define.word": {
+		discordgo.SpanishES:    {"palabra", "La palabra a definir"},
+		discordgo.SpanishLATAM: {"palabra", "La palabra a definir"},
+		discordgo.French:       {"mot", "Le mot à définir"},
+		discordgo.German:       {"wort", "Das zu definierende Wort"},
+	},
+}
+
+// localizeCommands fills in NameLocalizations and DescriptionLocalizations
+// from commandLocalizations before the commands are registered.
+func localizeCommands(cmds []*discordgo.ApplicationCommand) {
+	for _, cmd := range cmds {
+		if names, descs, ok := localizations(cmd.Name); ok {
+			cmd.NameLocalizations, cmd.DescriptionLocalizations = &names, &descs
+		}
+		for _, opt := range cmd.Options {
+			if names, descs, ok := localizations(cmd.Name + "." + opt.Name); ok {
+				opt.NameLocalizations, opt.DescriptionLocalizations = names, descs
+			}
+		}
+	}
+}
+
+func localizations(key string) (names, descs map[discordgo.Locale]string, ok bool) {
+	tr, ok := commandLocalizations[key]
+	if !ok {
+		return nil, nil, false
+	}
+	names, descs = map[discordgo.Locale]string{}, map[discordgo.Locale]string{}
+	for locale, l := range tr {
+		names[locale], descs[locale] = l.name, l.description
+	}
+	return names, descs, true
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ---------------------------
+// Prometheus metrics (served on the health server at /metrics)
+// ---------------------------
+
+var (
+	postsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "wotd_posts_total",
+		Help: "Scheduled Word of the Day messages successfully sent, per channel.",
+	})
+)
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"wotd.go/wotd"
+)
+
+// ---------------------------
+// Scheduler
+// ---------------------------
+
+// parsePostTimes parses a comma-separated list of HH:MM times, logging and
+// skipping invalid entries and dropping duplicates.
+func parsePostTimes(v string) []wotd.PostTime {
+	var out []wotd.PostTime
+	seen := map[wotd.PostTime]bool{}
+	for _, hm := range splitList(v) {
+		pt, ok := wotd.ParseHM(hm)
+		if !ok {
+			slog.Warn("[scheduler] ignoring bad POST_AT entry", "entry", hm)
+			continue
+		}
+		if !seen[pt] {
+			seen[pt] = true
+			out = append(out, pt)
+		}
+	}
+	return out
+}
+
+// target is one destination with its own schedule: the env config, or a
+// guild set up with /config.
+type target struct {
+	key      string // state key: "" for the env config, else the guild ID
+	channels []string
+	loc      *time.Location
+	sched    wotd.Schedule
+}
+
+// guildConfigs loads every /config'd guild, or none in the dev
+// environment, where only TEST_CHANNEL_ID is posted to.
+func (b *bot) guildConfigs() []GuildConfig {
+	if b.cfg.dev() {
+		return nil
+	}
+	gcs, err := b.store.GuildConfigs()
+	if err != nil {
+		slog.Error("[scheduler] could not load guild configs", "err", err)
+	}
+	return gcs
+}
+
+// targets returns the per-guild schedules from the store, falling back to
+// the env config when no guild has been configured.
+func (b *bot) targets() []target {
+	gcs := b.guildConfigs()
+	if len(gcs) == 0 {
+		if t, ok := b.envTarget(); ok {
+			return []target{t}
+		}
+		return nil
+	}
+	var out []target
+	for _, gc := range gcs {
+		if t, ok := b.guildTarget(gc); ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (b *bot) envTarget() (target, bool) {
+	cfg := b.cfg
+	if len(cfg.ChannelIDs) == 0 || cfg.TZ == "" || cfg.PostAt == "" {
+		slog.Info("[scheduler] env schedule skipped (CHANNEL_ID/TZ/POST_AT not fully set)")
+		return target{}, false
+	}
+	loc, err := time.LoadLocation(cfg.TZ)
+	if err != nil {
+		slog.Error("[scheduler] invalid TZ", "tz", cfg.TZ, "err", err)
+		return target{}, false
+	}
+	sc := wotd.Schedule{Times: parsePostTimes(cfg.PostAt), SkipWeekends: cfg.SkipWeekends}
+	if len(sc.Times) == 0 {
+		slog.Warn("[scheduler] skipped (no valid POST_AT times)", "post_at", cfg.PostAt)
+		return target{}, false
+	}
+	return target{channels: cfg.ChannelIDs, loc: loc, sched: sc}, true
+}
+
+// guildTarget fills unset guild fields from the env config.
+func (b *bot) guildTarget(gc GuildConfig) (target, bool) {
+	postAt := gc.PostAt
+	if postAt == "" {
+		postAt = b.cfg.PostAt
+	}
+	if gc.ChannelID == "" || postAt == "" {
+		return target{}, false // not fully configured yet
+	}
+	loc := b.cfg.location()
+	if gc.TZ != "" {
+		l, err := time.LoadLocation(gc.TZ)
+		if err != nil {
+			slog.Error("[scheduler] invalid guild TZ", "guild", gc.GuildID, "tz", gc.TZ, "err", err)
+			return target{}, false
+		}
+		loc = l
+	}
+	sc := wotd.Schedule{Times: parsePostTimes(postAt), SkipWeekends: b.cfg.SkipWeekends}
+	if len(sc.Times) == 0 {
+		return target{}, false
+	}
+	return target{key: gc.GuildID, channels: []string{gc.ChannelID}, loc: loc, sched: sc}, true
+}
+
+// targetFor is the schedule a guild posts on: its /config, or the env config
+// while no guild has been configured, the same way targets picks them. Once
+// any guild uses /config, one without a usable config has no target.
+func (b *bot) targetFor(guildID string) (target, bool) {
+	gcs := b.guildConfigs()
+	if len(gcs) == 0 {
+		return b.envTarget()
+	}
+	for _, gc := range gcs {
+		if gc.GuildID == guildID {
+			return b.guildTarget(gc)
+		}
+	}
+	return target{}, false
+}
+
+// historyKey is the target a guild's posts are recorded under in history,
+// matching targetFor: the env config's "" while no guild has been
+// configured, else the guild itself. DMs get the env config's.
+func (b *bot) historyKey(guildID string) string {
+	if len(b.guildConfigs()) == 0 {
+		return ""
+	}
+	return guildID
+}
+
+// nextDue returns the soonest run across targets and the targets due then.
+func nextDue(targets []target, now time.Time) (time.Time, []target) {
+	var next time.Time
+	var due []target
+	for _, t := range targets {
+		n := t.sched.Next(now.In(t.loc))
+		switch {
+		case n.IsZero():
+		case next.IsZero() || n.Before(next):
+			next, due = n, []target{t}
+		case n.Equal(next):
+			due = append(due, t)
+		}
+	}
+	return next, due
+}
+
+// postWOTD picks a word and sends it to every channel of the target, logging
+// per-channel failures; with DRY_RUN the message is logged instead. On any
+// success the word goes into history and the post time into state, except in
+// a dry run, which leaves both (and a /setword pin) alone. Returns the words
+// and how many channels they reached.
+func (b *bot) postWOTD(ctx context.Context, t target) ([]wotd.WordData, int) {
+	ctx = wotd.WithRequestID(ctx)
+	log := wotd.LogFrom(ctx)
+	pinned := b.state.Override(t.key)
+	words, ok := b.pickWords(ctx, b.prefsFor(t.key), b.cfg.WordsPerPost, pinned)
+	if ctx.Err() != nil {
+		return words, 0 // shutting down or timed out; don't post a fallback
+	}
+	if !ok {
+		log.Warn("[scheduler] no word with a definition found, skipping post", "target", t.key)
+		return words, 0
+	}
+	if b.cfg.SpoilerDefinition {
+		for n := range words {
+			words[n] = wotd.Spoilered(words[n])
+		}
+	}
+	date := b.postDate(t.loc)
+	errs := sendAll(t.channels, b.cfg.SendConcurrency, func(channelID string) error {
+		if b.cfg.DryRun {
+			log.Info("[dry-run] would post", "channel", channelID, "message", strings.Join(wotd.FormatWOTDs(words, date), "\n\n"))
+			return nil
+		}
+		postTo, err := b.postChannel(channelID, t.loc)
+		if err != nil {
+			return err
+		}
+		if err := sendWOTDs(b.posterFor(channelID), postTo, words, b.cfg.PlainText, date); err != nil {
+			return err
+		}
+		postsTotal.Inc()
+		b.recordPost(kindScheduled)
+		return nil
+	})
+	sent := 0
+	for n, err := range errs {
+		if err != nil {
+			log.Error("[scheduler] send failed", "channel", t.channels[n], "err", err)
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return words, 0
+	}
+	if b.cfg.DryRun {
+		return words, sent // nothing was posted, so history and state stay as they are
+	}
+	now := time.Now()
+	for _, w := range words {
+		if w.Word == "" {
+			continue
+		}
+		if err := b.hist.AddWord(w.Word, t.key, now); err != nil {
+			slog.Error("[history] save failed", "err", err)
+		}
+	}
+	if err := b.state.MarkPosted(t.key, now); err != nil {
+		slog.Error("[state] save failed", "err", err)
+	}
+	if pinned != "" {
+		if err := b.state.ClearOverride(t.key, pinned); err != nil {
+			slog.Error("[state] save failed", "err", err)
+		}
+	}
+	return words, sent
+}
+
+// pickWords runs wotd.GetWOTD n times for one post, never picking the same word
+// twice. A non-empty pinned word (/setword) comes first instead of a random
+// one, with or without a definition. ok is false only if the first pick found
+// nothing to post; a later miss just makes the post shorter.
+func (b *bot) pickWords(ctx context.Context, p wotd.Prefs, n int, pinned string) (words []wotd.WordData, ok bool) {
+	picked := map[string]bool{}
+	if pinned != "" {
+		w, err := wotd.FetchDefinition(ctx, pinned, p.Lang)
+		if err != nil {
+			wotd.LogFrom(ctx).Warn("[scheduler] no definition for the pinned word, posting it anyway", "word", pinned, "err", err)
+			w = wotd.WordData{Word: pinned}
+		}
+		words = append(words, w)
+		picked[strings.ToLower(pinned)] = true
+	}
+	reported := b.reportedFunc()
+	reject := func(word string) bool {
+		return picked[strings.ToLower(word)] || reported != nil && reported(word)
+	}
+	for len(words) < max(n, 1) {
+		w, ok := wotd.GetWOTD(ctx, b.cfg.WOTDRetries, wotd.NewSelector(p, b.hist, reject), p.Lang)
+		if len(words) == 0 && !ok {
+			return nil, false
+		}
+		if !ok || len(words) > 0 && w.Word == "" || ctx.Err() != nil {
+			break
+		}
+		words = append(words, w)
+		if w.Word == "" {
+			break // nothing could be fetched; the post says so
+		}
+		picked[strings.ToLower(w.Word)] = true
+	}
+	return words, true
+}
+
+// sendAll runs send for every channel, at most limit at a time, and
+// returns each channel's error in order once all of them are done. A send
+// that panics is logged and counts as that channel's error, since runCycle's
+// recover can't catch a panic on another goroutine.
+func sendAll(channels []string, limit int, send func(channelID string) error) []error {
+	if limit < 1 {
+		limit = 1
+	}
+	errs := make([]error, len(channels))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for n, channelID := range channels {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("[scheduler] panic while sending", "channel", channelID, "panic", r, "stack", string(debug.Stack()))
+					errs[n] = fmt.Errorf("send panicked: %v", r)
+				}
+			}()
+			errs[n] = send(channelID)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// postDate is today's date in loc for SHOW_DATE, e.g. "Monday, June 3"
+// (DATE_LAYOUT), or "" when dates are off.
+func (b *bot) postDate(loc *time.Location) string {
+	if !b.cfg.ShowDate {
+		return ""
+	}
+	return time.Now().In(loc).Format(b.cfg.DateLayout)
+}
+
+// postScheduled posts for each due target, then DMs subscribers the first
+// word that made it out, unless they already got one today.
+func (b *bot) postScheduled(ctx context.Context, due []target) {
+	var dm []wotd.WordData
+	for _, t := range due {
+		if words, sent := b.postWOTD(ctx, t); sent > 0 && dm == nil && words[0].Word != "" {
+			dm = words
+		}
+	}
+	if dm != nil {
+		b.notifySubscribers(dm)
+	}
+}
+
+// notifySubscribers DMs the word to every /subscribe'd user, at most once a
+// day in TZ: with several schedules (guilds, POST_AT times) only the first
+// post of the day goes out by DM. Users with DMs disabled (or who left every
+// shared server) are logged and skipped.
+func (b *bot) notifySubscribers(words []wotd.WordData) {
+	if b.cfg.dev() {
+		slog.Info("[subscribers] dev environment, not sending DMs", "word", words[0].Word)
+		return
+	}
+	loc := b.cfg.location()
+	if wotd.SameDay(b.state.DMedAt().In(loc), time.Now().In(loc)) {
+		slog.Debug("[subscribers] already sent today's word", "word", words[0].Word)
+		return
+	}
+	users, err := b.store.Subscribers()
+	if err != nil {
+		slog.Error("[subscribers] could not load", "err", err)
+		return
+	}
+	for _, userID := range users {
+		if b.cfg.DryRun {
+			slog.Info("[dry-run] would DM", "user", userID, "word", words[0].Word, "words", len(words))
+			continue
+		}
+		ch, err := b.s.UserChannelCreate(userID)
+		if err == nil {
+			err = sendWOTDs(sessionPoster{b.s}, ch.ID, words, b.cfg.PlainText, b.postDate(b.cfg.location()))
+		}
+		if err != nil {
+			slog.Warn("[subscribers] DM failed, skipping", "user", userID, "err", err)
+		}
+	}
+	if b.cfg.DryRun {
+		return // nothing was sent, so state stays as it is
+	}
+	if err := b.state.MarkDMed(time.Now()); err != nil {
+		slog.Error("[state] save failed", "err", err)
+	}
+}
+
+// catchUp posts right away for targets whose post earlier today was missed,
+// unless the miss is older than CATCHUP_MAX_AGE. It runs as a cycle of its
+// own, so a panic there doesn't stop the scheduler either.
+func (b *bot) catchUp(ctx context.Context, targets []target) {
+	var missed []target
+	for _, t := range targets {
+		now := time.Now().In(t.loc)
+		if prev := t.sched.Prev(now); wotd.SameDay(prev, now) && b.state.LastPost(t.key).Before(prev) {
+			if max := b.cfg.CatchupMaxAge; max > 0 && now.Sub(prev) > max {
+				slog.Info("[scheduler] missed post too old, not catching up", "target", t.key, "missed", prev.Format(time.RFC1123), "max_age", max)
+				continue
+			}
+			slog.Info("[scheduler] missed post, catching up", "target", t.key, "missed", prev.Format(time.RFC1123))
+			missed = append(missed, t)
+		}
+	}
+	b.runCycle(ctx, missed)
+}
+
+// runCycle is one scheduled run. A panic in picking or sending a word is
+// logged and swallowed, so it costs that run rather than stopping posts for
+// good.
+func (b *bot) runCycle(ctx context.Context, due []target) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("[scheduler] panic during scheduled post, skipping this run", "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	b.postScheduled(ctx, due)
+}
+
+// intervalTargets are the destinations for INTERVAL mode: every guild with a
+// /config channel, else CHANNEL_ID. Post times don't apply.
+func (b *bot) intervalTargets() []target {
+	gcs := b.guildConfigs()
+	var out []target
+	for _, gc := range gcs {
+		if gc.ChannelID == "" {
+			continue
+		}
+		loc := b.cfg.location()
+		if l, err := time.LoadLocation(gc.TZ); err == nil && gc.TZ != "" {
+			loc = l
+		}
+		out = append(out, target{key: gc.GuildID, channels: []string{gc.ChannelID}, loc: loc})
+	}
+	if len(out) == 0 && len(b.cfg.ChannelIDs) > 0 {
+		out = append(out, target{channels: b.cfg.ChannelIDs, loc: b.cfg.location()})
+	}
+	return out
+}
+
+// scheduleInterval posts to every target each INTERVAL until ctx is
+// cancelled, for trying out formatting changes without waiting a day. It
+// replaces scheduleDaily; catch-up and jitter don't apply.
+func (b *bot) scheduleInterval(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		slog.Info("[scheduler] posting on a fixed interval", "every", b.cfg.Interval)
+		ticker := time.NewTicker(b.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				slog.Info("[scheduler] stopping")
+				return
+			case <-ticker.C:
+				b.runCycle(ctx, b.intervalTargets())
+			}
+		}
+	}()
+}
+
+// slot is one planned scheduled run: the targets due at next, posted at
+// fire, which POST_JITTER_SECONDS may put after next.
+type slot struct {
+	next, fire time.Time
+	due        []target
+}
+
+// planSlot plans the first run after now. A pending slot whose time has
+// passed but whose jittered post hasn't gone out yet is kept as it is:
+// planning afresh would pick the following slot and drop this one's post.
+func (b *bot) planSlot(now time.Time, pending slot) slot {
+	if !pending.next.IsZero() && !now.Before(pending.next) {
+		return pending
+	}
+	next, due := nextDue(b.targets(), now)
+	sl := slot{next: next, fire: next, due: due}
+	if !next.IsZero() && b.cfg.PostJitter > 0 {
+		following, _ := nextDue(b.targets(), next)
+		sl.fire = wotd.Jitter(next, following, b.cfg.PostJitter)
+		slog.Info("[scheduler] jittered post time", "slot", next.Format(time.RFC1123), "at", sl.fire.Format(time.RFC1123))
+	}
+	return sl
+}
+
+// scheduleDaily posts for every target at its post times until ctx is
+// cancelled, re-planning whenever guild configs change. The goroutine is
+// tracked in wg so main can wait for it before closing the session.
+// With catch-up enabled, a post missed earlier today is sent right away.
+func (b *bot) scheduleDaily(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if b.cfg.Catchup {
+			b.catchUp(ctx, b.targets())
+		}
+		var pending slot
+		for {
+			sl := b.planSlot(time.Now(), pending)
+			pending = slot{}
+			var wake <-chan time.Time
+			var timer *time.Timer
+			if sl.next.IsZero() {
+				slog.Info("[scheduler] nothing scheduled")
+			} else {
+				slog.Debug("[scheduler] next WOTD", "at", sl.fire.Format(time.RFC1123), "targets", len(sl.due))
+				timer = time.NewTimer(time.Until(sl.fire))
+				wake = timer.C
+			}
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				slog.Info("[scheduler] stopping")
+				return
+			case <-b.reload:
+				if timer != nil {
+					timer.Stop()
+				}
+				slog.Debug("[scheduler] config changed, re-planning")
+				pending = sl
+				continue
+			case <-wake:
+			}
+			b.runCycle(ctx, sl.due)
+		}
+	}()
+}
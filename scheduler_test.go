@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wotd.go/wotd"
+)
+
+// panicSource makes the selector panic on its first draw, then hands out
+// the same word.
+type panicSource struct{ calls atomic.Int32 }
+
+func (p *panicSource) Next(context.Context) (string, error) {
+	if p.calls.Add(1) == 1 {
+		panic("selector exploded")
+	}
+	return "fortitude", nil
+}
+
+// echoProvider defines every word it is asked about.
+type echoProvider struct{}
+
+func (echoProvider) Name() string { return "echo" }
+
+func (echoProvider) Define(_ context.Context, word, _ string) (wotd.WordData, error) {
+	return wotd.WordData{Word: word, Meanings: []wotd.Meaning{{Definitions: []wotd.Definition{{Definition: "x"}}}}}, nil
+}
+
+// testBot is a bot with fresh history, state and store files and echoProvider
+// definitions, without a Discord session.
+func testBot(t *testing.T, cfg Config) *bot {
+	t.Helper()
+	store := testStore(t)
+	hist, err := loadHistory(store, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := loadState(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevProviders, prevCache := wotd.Providers, wotd.Definitions
+	wotd.Providers, wotd.Definitions = []wotd.DefinitionProvider{echoProvider{}}, nil
+	t.Cleanup(func() { wotd.Providers, wotd.Definitions = prevProviders, prevCache })
+	return newBot(nil, cfg, hist, state, store)
+}
+
+// testStore opens a fresh database for the test.
+func testStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := openStore(filepath.Join(t.TempDir(), "wotd.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// withSource swaps the word source for the test.
+func withSource(t *testing.T, src wotd.WordSource) {
+	t.Helper()
+	prev := wotd.Source
+	wotd.Source = src
+	t.Cleanup(func() { wotd.Source = prev })
+}
+
+func TestSchedulerSurvivesPanic(t *testing.T) {
+	src := &panicSource{}
+	withSource(t, src)
+	b := testBot(t, Config{ChannelIDs: []string{"chan"}, DryRun: true, Interval: 10 * time.Millisecond, WOTDRetries: 1, SendConcurrency: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	b.scheduleInterval(ctx, &wg)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for src.calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("selector called %d times, want the loop to keep going after the panic", src.calls.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// fixedSource always hands out the same word.
+type fixedSource string
+
+func (s fixedSource) Next(context.Context) (string, error) { return string(s), nil }
+
+func TestDryRunLeavesHistoryAndState(t *testing.T) {
+	withSource(t, fixedSource("fortitude"))
+	b := testBot(t, Config{DryRun: true, WOTDRetries: 1, WordsPerPost: 1, SendConcurrency: 1})
+	words, sent := b.postWOTD(context.Background(), target{channels: []string{"chan"}, loc: time.UTC})
+	if sent != 1 || len(words) != 1 || words[0].Word != "fortitude" {
+		t.Fatalf("postWOTD = %v, %d; want fortitude logged for one channel", words, sent)
+	}
+	if b.hist.Contains("fortitude") {
+		t.Error("dry run added the word to history")
+	}
+	if !b.state.LastPost("").IsZero() {
+		t.Errorf("dry run recorded a post time: %v", b.state.LastPost(""))
+	}
+}
+
+func TestCatchUpSurvivesPanic(t *testing.T) {
+	src := &panicSource{}
+	withSource(t, src)
+	b := testBot(t, Config{ChannelIDs: []string{"chan"}, TZ: "UTC", PostAt: "00:00", DryRun: true, WOTDRetries: 1, WordsPerPost: 1, SendConcurrency: 1})
+	b.catchUp(context.Background(), b.targets())
+
+	if got := src.calls.Load(); got != 1 {
+		t.Fatalf("source called %d times, want the one panicking catch-up pick", got)
+	}
+	if words := b.hist.RecentWords("", 10); len(words) != 0 {
+		t.Errorf("history has %v, want no post from the panicked catch-up", words)
+	}
+	if at := b.state.LastPost(""); !at.IsZero() {
+		t.Errorf("catch-up marked a post at %v although it panicked", at)
+	}
+	now := time.Now().UTC()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if sl := b.planSlot(now, slot{}); !sl.next.Equal(tomorrow) || len(sl.due) != 1 {
+		t.Errorf("next run %v for %d targets, want %v for the env target", sl.next, len(sl.due), tomorrow)
+	}
+}
+
+func TestSendAllSurvivesPanic(t *testing.T) {
+	errs := sendAll([]string{"a", "b", "c"}, 2, func(channelID string) error {
+		if channelID == "b" {
+			panic("send exploded")
+		}
+		return nil
+	})
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("sendAll errors = %v, want only b to fail", errs)
+	}
+	if errs[1] == nil {
+		t.Error("panicking send for b returned no error")
+	}
+}
+
+func TestTargetForFallsBackOnlyWithoutGuilds(t *testing.T) {
+	b := testBot(t, Config{ChannelIDs: []string{"env-chan"}, TZ: "UTC", PostAt: "09:00"})
+	if got, ok := b.targetFor("guild2"); !ok || got.key != "" {
+		t.Fatalf("targetFor with no guilds = %+v, %v; want the env target", got, ok)
+	}
+	if err := b.store.SetGuildChannel("guild1", "chan1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := b.targetFor("guild1"); !ok || got.key != "guild1" {
+		t.Errorf("targetFor(guild1) = %+v, %v; want its /config target", got, ok)
+	}
+	if got, ok := b.targetFor("guild2"); ok {
+		t.Errorf("targetFor(guild2) = %+v; want none once another guild is configured", got)
+	}
+}
+
+func TestPlanSlotKeepsJitteredSlotAcrossReload(t *testing.T) {
+	b := testBot(t, Config{ChannelIDs: []string{"chan"}, TZ: "UTC", PostAt: "09:00", PostJitter: time.Hour})
+	slotAt := time.Date(2026, 6, 3, 9, 0, 0, 0, time.UTC)
+	pending := b.planSlot(slotAt.Add(-time.Minute), slot{})
+	if !pending.next.Equal(slotAt) || len(pending.due) != 1 {
+		t.Fatalf("planned %v for %d targets, want %v for the env target", pending.next, len(pending.due), slotAt)
+	}
+	pending.fire = slotAt.Add(45 * time.Minute)
+
+	// A /config reload at 09:30, after the slot but before its jittered post.
+	if got := b.planSlot(slotAt.Add(30*time.Minute), pending); !got.next.Equal(slotAt) || !got.fire.Equal(pending.fire) {
+		t.Errorf("re-planned to %v (fire %v), want the pending %v slot kept", got.next, got.fire, slotAt)
+	}
+	// Before the slot, a reload plans afresh.
+	if got := b.planSlot(slotAt.Add(-time.Minute), pending); !got.next.Equal(slotAt) || got.fire.Before(slotAt) {
+		t.Errorf("re-planned before the slot to %v (fire %v), want %v again", got.next, got.fire, slotAt)
+	}
+}
+
+func TestNotifySubscribersOncePerDay(t *testing.T) {
+	b := testBot(t, Config{TZ: "UTC"})
+	if err := b.store.AddSubscriber("user1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.state.MarkDMed(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// The bot has no session, so a DM attempt would panic.
+	b.notifySubscribers([]wotd.WordData{{Word: "fortitude"}})
+}
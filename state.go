@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// ---------------------------
+// Scheduler state (SQLite store)
+// ---------------------------
+
+// State is small bookkeeping the scheduler needs across restarts, kept in the
+// store and cached here. It is shared by the scheduler and /post, so access
+// goes through mu. Target keys are "" for the env schedule, else the guild
+// ID.
+type State struct {
+	mu        sync.Mutex
+	st        *Store
+	lastPosts map[string]time.Time // last successful post per target
+	overrides map[string]string    // /setword word for a target's next post
+	lastDM    time.Time            // last time subscribers were sent the word
+}
+
+func loadState(st *Store) (*State, error) {
+	s := &State{st: st, lastPosts: map[string]time.Time{}, overrides: map[string]string{}}
+	var err error
+	if s.lastPosts, err = st.LastPosts(); err != nil {
+		s.lastPosts = map[string]time.Time{}
+		return s, err
+	}
+	if s.overrides, err = st.Overrides(); err != nil {
+		s.overrides = map[string]string{}
+		return s, err
+	}
+	s.lastDM, err = st.LastDM()
+	return s, err
+}
+
+// legacyState is the state JSON file from before state lived in the store.
+type legacyState struct {
+	EnvPost    time.Time            `json:"last_post"`
+	GuildPosts map[string]time.Time `json:"guild_posts"`
+	Overrides  map[string]string    `json:"overrides"`
+	LastDM     time.Time            `json:"last_dm"`
+}
+
+// importStateFile moves a state JSON file into st and renames the file so
+// that happens only once. A missing file is nothing to import.
+func importStateFile(st *Store, path string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var ls legacyState
+	if err := json.Unmarshal(b, &ls); err != nil {
+		return err
+	}
+	posts := ls.GuildPosts
+	if posts == nil {
+		posts = map[string]time.Time{}
+	}
+	posts[""] = ls.EnvPost
+	for target, at := range posts {
+		if at.IsZero() {
+			continue
+		}
+		if err := st.SetLastPost(target, at); err != nil {
+			return err
+		}
+	}
+	for target, word := range ls.Overrides {
+		if err := st.SetOverride(target, word); err != nil {
+			return err
+		}
+	}
+	if !ls.LastDM.IsZero() {
+		if err := st.SetLastDM(ls.LastDM); err != nil {
+			return err
+		}
+	}
+	return os.Rename(path, path+".imported")
+}
+
+// LastPost is the last successful scheduled post for a target key.
+func (s *State) LastPost(key string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastPosts[key]
+}
+
+// MarkPosted records a successful scheduled post.
+func (s *State) MarkPosted(key string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastPosts[key] = at
+	return s.st.SetLastPost(key, at)
+}
+
+// Override is the word pinned with /setword for a target key's next post,
+// or "".
+func (s *State) Override(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.overrides[key]
+}
+
+// SetOverride pins word for a target key's next post.
+func (s *State) SetOverride(key, word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[key] = word
+	return s.st.SetOverride(key, word)
+}
+
+// ClearOverride drops a used pin, unless /setword replaced it meanwhile.
+func (s *State) ClearOverride(key, word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overrides[key] != word {
+		return nil
+	}
+	delete(s.overrides, key)
+	return s.st.DeleteOverride(key)
+}
+
+// DMedAt is when subscribers were last sent the word.
+func (s *State) DMedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastDM
+}
+
+// MarkDMed records that subscribers were sent the word.
+func (s *State) MarkDMed(at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastDM = at
+	return s.st.SetLastDM(at)
+}
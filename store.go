@@ -0,0 +1,400 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ---------------------------
+// SQLite store
+// ---------------------------
+
+// GuildConfig overrides where and when a guild gets its scheduled post, and
+// which words it gets. Empty fields fall back to the env config.
+type GuildConfig struct {
+	GuildID    string
+	ChannelID  string
+	TZ         string
+	PostAt     string
+	Lang       string
+	Difficulty string
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+// migrations bring the database up to date, in order. PRAGMA user_version
+// records how many have run; each must also be safe on a database created
+// before versioning, which reports version 0.
+var migrations = []func(*sql.Tx) error{
+	execMigration(schema),
+	// Databases created before lang/difficulty existed lack the columns.
+	func(tx *sql.Tx) error {
+		for _, col := range []string{"lang", "difficulty"} {
+			if err := addColumn(tx, "guild_config", col, `TEXT NOT NULL DEFAULT ''`); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	execMigration(`
+CREATE TABLE IF NOT EXISTS feedback (
+	word        TEXT NOT NULL, -- lowercased
+	user_id     TEXT NOT NULL,
+	reason      TEXT NOT NULL,
+	reported_at INTEGER NOT NULL -- unix seconds
+);
+CREATE INDEX IF NOT EXISTS feedback_word ON feedback (word);`),
+	// History and scheduler state used to be JSON files; importHistoryFile
+	// and importStateFile move their contents in.
+	execMigration(`
+CREATE TABLE IF NOT EXISTS history (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT, -- posting order
+	word      TEXT NOT NULL,                     -- lowercased
+	posted_at INTEGER NOT NULL,                  -- unix seconds
+	target    TEXT NOT NULL DEFAULT ''           -- "" for the env schedule, else the guild ID
+);
+CREATE TABLE IF NOT EXISTS last_posts (
+	target    TEXT PRIMARY KEY,
+	posted_at INTEGER NOT NULL -- unix seconds
+);
+CREATE TABLE IF NOT EXISTS overrides (
+	target TEXT PRIMARY KEY,
+	word   TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS last_dm (
+	id      INTEGER PRIMARY KEY CHECK (id = 1),
+	sent_at INTEGER NOT NULL -- unix seconds
+);`),
+}
+
+func execMigration(query string) func(*sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS guild_config (
+	guild_id   TEXT PRIMARY KEY,
+	channel_id TEXT NOT NULL DEFAULT '',
+	tz         TEXT NOT NULL DEFAULT '',
+	post_at    TEXT NOT NULL DEFAULT '',
+	lang       TEXT NOT NULL DEFAULT '',
+	difficulty TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS subscribers (
+	user_id       TEXT PRIMARY KEY,
+	subscribed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS posts (
+	posted_at INTEGER NOT NULL, -- unix seconds
+	kind      TEXT NOT NULL     -- "scheduled" or "on-demand"
+);
+CREATE INDEX IF NOT EXISTS posts_posted_at ON posts (posted_at);`
+
+func openStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// migrate runs the migrations the database hasn't seen yet, each in its own
+// transaction together with the version bump.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	for ; version < len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := migrations[version](tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		// PRAGMA doesn't take bind parameters.
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column unless the table already has it.
+func addColumn(tx *sql.Tx, table, column, decl string) error {
+	rows, err := tx.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + decl)
+	return err
+}
+
+func (st *Store) Close() error {
+	return st.db.Close()
+}
+
+func (st *Store) GuildConfigs() ([]GuildConfig, error) {
+	rows, err := st.db.Query(`SELECT guild_id, channel_id, tz, post_at, lang, difficulty FROM guild_config ORDER BY guild_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []GuildConfig
+	for rows.Next() {
+		var gc GuildConfig
+		if err := rows.Scan(&gc.GuildID, &gc.ChannelID, &gc.TZ, &gc.PostAt, &gc.Lang, &gc.Difficulty); err != nil {
+			return nil, err
+		}
+		out = append(out, gc)
+	}
+	return out, rows.Err()
+}
+
+// GuildConfig returns the guild's row, or a zero config with GuildID set.
+func (st *Store) GuildConfig(guildID string) (GuildConfig, error) {
+	gc := GuildConfig{GuildID: guildID}
+	err := st.db.QueryRow(`SELECT channel_id, tz, post_at, lang, difficulty FROM guild_config WHERE guild_id = ?`, guildID).
+		Scan(&gc.ChannelID, &gc.TZ, &gc.PostAt, &gc.Lang, &gc.Difficulty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return gc, nil
+	}
+	return gc, err
+}
+
+func (st *Store) SetGuildChannel(guildID, channelID string) error {
+	_, err := st.db.Exec(`INSERT INTO guild_config (guild_id, channel_id) VALUES (?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET channel_id = excluded.channel_id`, guildID, channelID)
+	return err
+}
+
+func (st *Store) SetGuildTime(guildID, tz, postAt string) error {
+	_, err := st.db.Exec(`INSERT INTO guild_config (guild_id, tz, post_at) VALUES (?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET tz = excluded.tz, post_at = excluded.post_at`, guildID, tz, postAt)
+	return err
+}
+
+func (st *Store) SetGuildLanguage(guildID, lang string) error {
+	_, err := st.db.Exec(`INSERT INTO guild_config (guild_id, lang) VALUES (?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET lang = excluded.lang`, guildID, lang)
+	return err
+}
+
+func (st *Store) SetGuildDifficulty(guildID, difficulty string) error {
+	_, err := st.db.Exec(`INSERT INTO guild_config (guild_id, difficulty) VALUES (?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET difficulty = excluded.difficulty`, guildID, difficulty)
+	return err
+}
+
+func (st *Store) AddSubscriber(userID string) error {
+	_, err := st.db.Exec(`INSERT OR IGNORE INTO subscribers (user_id) VALUES (?)`, userID)
+	return err
+}
+
+// RemoveSubscriber reports whether the user was subscribed.
+func (st *Store) RemoveSubscriber(userID string) (bool, error) {
+	res, err := st.db.Exec(`DELETE FROM subscribers WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (st *Store) Subscribers() ([]string, error) {
+	rows, err := st.db.Query(`SELECT user_id FROM subscribers ORDER BY subscribed_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// Kinds of post recorded for /stats.
+const (
+	kindScheduled = "scheduled"
+	kindOnDemand  = "on-demand"
+)
+
+// RecordPost logs one word sent to a channel.
+func (st *Store) RecordPost(kind string, at time.Time) error {
+	_, err := st.db.Exec(`INSERT INTO posts (posted_at, kind) VALUES (?, ?)`, at.Unix(), kind)
+	return err
+}
+
+// PostsSince counts posts of any kind at or after t.
+func (st *Store) PostsSince(t time.Time) (int, error) {
+	var n int
+	err := st.db.QueryRow(`SELECT COUNT(*) FROM posts WHERE posted_at >= ?`, t.Unix()).Scan(&n)
+	return n, err
+}
+
+// AddFeedback records a /feedback report about a word.
+func (st *Store) AddFeedback(word, userID, reason string, at time.Time) error {
+	_, err := st.db.Exec(`INSERT INTO feedback (word, user_id, reason, reported_at) VALUES (?, ?, ?, ?)`,
+		strings.ToLower(word), userID, reason, at.Unix())
+	return err
+}
+
+// Reporters counts the distinct users who reported a word.
+func (st *Store) Reporters(word string) (int, error) {
+	var n int
+	err := st.db.QueryRow(`SELECT COUNT(DISTINCT user_id) FROM feedback WHERE word = ?`, strings.ToLower(word)).Scan(&n)
+	return n, err
+}
+
+// HistoryEntries returns the newest limit history entries, oldest first.
+func (st *Store) HistoryEntries(limit int) ([]HistoryEntry, error) {
+	rows, err := st.db.Query(`SELECT word, posted_at, target FROM
+		(SELECT id, word, posted_at, target FROM history ORDER BY id DESC LIMIT ?) ORDER BY id`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		var at int64
+		if err := rows.Scan(&e.Word, &at, &e.Target); err != nil {
+			return nil, err
+		}
+		e.PostedAt = time.Unix(at, 0)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// AddHistory records posted words and drops all but the newest keep
+// entries.
+func (st *Store) AddHistory(keep int, entries ...HistoryEntry) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, e := range entries {
+		if _, err := tx.Exec(`INSERT INTO history (word, posted_at, target) VALUES (?, ?, ?)`,
+			strings.ToLower(e.Word), e.PostedAt.Unix(), e.Target); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM history WHERE id NOT IN (SELECT id FROM history ORDER BY id DESC LIMIT ?)`, keep); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LastPosts maps each target ("" = env schedule) to its last successful
+// scheduled post.
+func (st *Store) LastPosts() (map[string]time.Time, error) {
+	rows, err := st.db.Query(`SELECT target, posted_at FROM last_posts`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]time.Time{}
+	for rows.Next() {
+		var target string
+		var at int64
+		if err := rows.Scan(&target, &at); err != nil {
+			return nil, err
+		}
+		out[target] = time.Unix(at, 0)
+	}
+	return out, rows.Err()
+}
+
+func (st *Store) SetLastPost(target string, at time.Time) error {
+	_, err := st.db.Exec(`INSERT INTO last_posts (target, posted_at) VALUES (?, ?)
+		ON CONFLICT(target) DO UPDATE SET posted_at = excluded.posted_at`, target, at.Unix())
+	return err
+}
+
+// Overrides maps each target to its /setword word.
+func (st *Store) Overrides() (map[string]string, error) {
+	rows, err := st.db.Query(`SELECT target, word FROM overrides`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var target, word string
+		if err := rows.Scan(&target, &word); err != nil {
+			return nil, err
+		}
+		out[target] = word
+	}
+	return out, rows.Err()
+}
+
+func (st *Store) SetOverride(target, word string) error {
+	_, err := st.db.Exec(`INSERT INTO overrides (target, word) VALUES (?, ?)
+		ON CONFLICT(target) DO UPDATE SET word = excluded.word`, target, word)
+	return err
+}
+
+func (st *Store) DeleteOverride(target string) error {
+	_, err := st.db.Exec(`DELETE FROM overrides WHERE target = ?`, target)
+	return err
+}
+
+// LastDM is when subscribers were last sent the word, or the zero time.
+func (st *Store) LastDM() (time.Time, error) {
+	var at int64
+	err := st.db.QueryRow(`SELECT sent_at FROM last_dm`).Scan(&at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(at, 0), nil
+}
+
+func (st *Store) SetLastDM(at time.Time) error {
+	_, err := st.db.Exec(`INSERT INTO last_dm (id, sent_at) VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET sent_at = excluded.sent_at`, at.Unix())
+	return err
+}
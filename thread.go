@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ---------------------------
+// Threads
+// ---------------------------
+
+// Minutes of inactivity before a post thread auto-archives (one day).
+const threadArchiveMinutes = 1440
+
+// threadName expands THREAD_NAME for a post on now's date, e.g.
+// "Word of the Day {date}" → "Word of the Day 2024-06-03".
+func threadName(tmpl string, now time.Time) string {
+	return strings.ReplaceAll(tmpl, "{date}", now.Format("2006-01-02"))
+}
+
+// postChannel is where a scheduled post to channelID goes. Without
+// THREAD_NAME, or when channelID is already a thread, that's channelID
+// itself. Otherwise it's the active thread of that name under the channel,
+// started if there isn't one. If the thread can't be found or started the
+// post goes to the parent channel, except for a forum, which takes no plain
+// messages: then the post fails. Webhook posts never go into a thread.
+func (b *bot) postChannel(channelID string, loc *time.Location) (string, error) {
+	if b.cfg.ThreadName == "" || channelID == webhookChannel {
+		return channelID, nil
+	}
+	ch, err := b.s.State.Channel(channelID)
+	if err != nil {
+		if ch, err = b.s.Channel(channelID); err != nil {
+			slog.Warn("[thread] could not look up channel, posting to it directly", "channel", channelID, "err", err)
+			return channelID, nil
+		}
+	}
+	if ch.IsThread() {
+		return channelID, nil
+	}
+	name := threadName(b.cfg.ThreadName, time.Now().In(loc))
+	if active, err := b.s.GuildThreadsActive(ch.GuildID); err == nil {
+		for _, th := range active.Threads {
+			if th.ParentID == channelID && th.Name == name {
+				return th.ID, nil
+			}
+		}
+	} else {
+		slog.Warn("[thread] could not list active threads", "guild", ch.GuildID, "err", err)
+	}
+	var th *discordgo.Channel
+	if ch.Type == discordgo.ChannelTypeGuildForum {
+		// Forum posts need a starter message; the word follows inside.
+		th, err = b.s.ForumThreadStart(channelID, name, threadArchiveMinutes, name)
+	} else {
+		th, err = b.s.ThreadStart(channelID, name, discordgo.ChannelTypeGuildPublicThread, threadArchiveMinutes)
+	}
+	if err != nil && ch.Type == discordgo.ChannelTypeGuildForum {
+		slog.Error("[thread] could not start forum post, nowhere else to post", "channel", channelID, "thread", name, "err", err)
+		return "", fmt.Errorf("start forum post %q: %w", name, err)
+	}
+	if err != nil {
+		slog.Warn("[thread] could not start thread, posting to the channel", "channel", channelID, "thread", name, "err", err)
+		return channelID, nil
+	}
+	slog.Info("[thread] started thread", "channel", channelID, "thread", name, "id", th.ID)
+	return th.ID, nil
+}
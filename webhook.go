@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ---------------------------
+// Posting
+// ---------------------------
+
+// poster sends one message to a channel, as the bot or through a webhook.
+type poster interface {
+	Post(channelID string, m *discordgo.MessageSend) error
+}
+
+// sessionPoster posts as the bot.
+type sessionPoster struct{ s *discordgo.Session }
+
+func (p sessionPoster) Post(channelID string, m *discordgo.MessageSend) error {
+	_, err := p.s.ChannelMessageSendComplex(channelID, m)
+	return err
+}
+
+// webhookChannel stands in for CHANNEL_ID when WEBHOOK_URL is set, so the
+// env schedule posts once, through the webhook, to the webhook's channel.
+const webhookChannel = "webhook"
+
+// webhookPoster posts through a Discord webhook (WEBHOOK_URL), under its
+// own name and avatar if set. The webhook picks the channel, so channelID
+// is ignored.
+type webhookPoster struct {
+	s         *discordgo.Session
+	id, token string
+	username  string // WEBHOOK_USERNAME; empty = the webhook's own name
+	avatarURL string // WEBHOOK_AVATAR; empty = the webhook's own avatar
+}
+
+func newWebhookPoster(s *discordgo.Session, cfg Config) (*webhookPoster, error) {
+	id, token, err := parseWebhookURL(cfg.WebhookURL)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookPoster{s: s, id: id, token: token, username: cfg.WebhookUsername, avatarURL: cfg.WebhookAvatar}, nil
+}
+
+func (p *webhookPoster) Post(_ string, m *discordgo.MessageSend) error {
+	_, err := p.s.WebhookExecute(p.id, p.token, true, &discordgo.WebhookParams{
+		Content:   m.Content,
+		Embeds:    m.Embeds,
+		Files:     m.Files,
+		Username:  p.username,
+		AvatarURL: p.avatarURL,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
+
+// parseWebhookURL splits a webhook URL such as
+// https://discord.com/api/webhooks/<id>/<token> into its ID and token.
+func parseWebhookURL(raw string) (id, token string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", errors.New("WEBHOOK_URL is not a valid URL") // the error would echo the token
+	}
+	rest, ok := strings.CutPrefix(strings.TrimSuffix(u.Path, "/"), "/api/")
+	if ok {
+		// Versioned URLs (/api/v10/webhooks/...) work the same.
+		if v, after, found := strings.Cut(rest, "/"); found && strings.HasPrefix(v, "v") {
+			rest = after
+		}
+	}
+	parts := strings.Split(rest, "/")
+	if !ok || u.Scheme != "https" || len(parts) != 3 || parts[0] != "webhooks" || parts[1] == "" || parts[2] == "" {
+		return "", "", errors.New("WEBHOOK_URL must look like https://discord.com/api/webhooks/<id>/<token>")
+	}
+	return parts[1], parts[2], nil
+}
+
+// posterFor is how a scheduled post to channelID goes out: through the
+// webhook for webhookChannel, else as the bot.
+func (b *bot) posterFor(channelID string) poster {
+	if channelID == webhookChannel && b.webhook != nil {
+		return b.webhook
+	}
+	return sessionPoster{b.s}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestParseWebhookURL(t *testing.T) {
+	tests := []struct {
+		url, wantID, wantToken string
+		wantErr                bool
+	}{
+		{"https://discord.com/api/webhooks/123/abc-DEF", "123", "abc-DEF", false},
+		{"https://discord.com/api/v10/webhooks/123/abc/", "123", "abc", false},
+		{"https://discordapp.com/api/webhooks/123/abc?wait=true", "123", "abc", false},
+		{"http://discord.com/api/webhooks/123/abc", "", "", true},
+		{"https://discord.com/api/webhooks/123", "", "", true},
+		{"https://discord.com/channels/123/456", "", "", true},
+		{"not a url", "", "", true},
+	}
+	for _, tt := range tests {
+		id, token, err := parseWebhookURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseWebhookURL(%q) err = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if id != tt.wantID || token != tt.wantToken {
+			t.Errorf("parseWebhookURL(%q) = %q, %q, want %q, %q", tt.url, id, token, tt.wantID, tt.wantToken)
+		}
+	}
+}
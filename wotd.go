@@ -1,236 +1,467 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/joho/godotenv"
+
+	"wotd.go/wotd"
 )
 
 // ---------------------------
-// API response types
+// Sending
 // ---------------------------
 
-type RandomWordResponse []string
+// Standing footer under every scheduled post and DM; set from POST_FOOTER in
+// main.
+var postFooter string
 
-type Definition struct {
-	Definition string   `json:"definition"`
-	Example    string   `json:"example"`
-	Synonyms   []string `json:"synonyms"`
-	Antonyms   []string `json:"antonyms"`
-}
+// With MULTI_EMBED, single-word embed posts get one embed per part of
+// speech. Set from config in main.
+var multiEmbed bool
 
-type Meaning struct {
-	PartOfSpeech string       `json:"partOfSpeech"`
-	Definitions  []Definition `json:"definitions"`
+// sendWOTD posts a word to a channel as an embed, or as text in plain mode.
+// A failed fetch (no word) is always sent as text. A non-empty date is
+// stamped below the message (SHOW_DATE), then postFooter. With RENDER_CARD
+// the text goes out with a rendered PNG card attached instead of the embed;
+// with MULTI_EMBED the embed is split per part of speech.
+func sendWOTD(out poster, channelID string, w wotd.WordData, plain bool, date string) error {
+	var card []byte
+	if renderCards && w.Word != "" {
+		var err error
+		if card, err = renderCard(w); err != nil {
+			slog.Error("[card] render failed, sending without it", "word", w.Word, "err", err)
+		}
+	}
+	if multiEmbed && card == nil && !plain && w.Word != "" {
+		return sendEmbeds(out, channelID, wotd.BuildMeaningEmbeds(w, maxEmbedsPerMessage), date)
+	}
+	return retryRateLimited(channelID, func() error {
+		if card != nil {
+			// The text stays as the body so screen readers get the word too.
+			return out.Post(channelID, &discordgo.MessageSend{
+				Content: wotd.WithFooter(wotd.FitMessage(wotd.WithDate(wotd.FormatWOTD(w), date)), postFooter),
+				Files:   []*discordgo.File{{Name: "wotd.png", ContentType: "image/png", Reader: bytes.NewReader(card)}},
+			})
+		}
+		if plain || w.Word == "" {
+			return out.Post(channelID, &discordgo.MessageSend{Content: wotd.WithFooter(wotd.FitMessage(wotd.WithDate(wotd.FormatWOTD(w), date)), postFooter)})
+		}
+		embed := wotd.BuildWOTDEmbed(w)
+		dateFooter(embed, date)
+		wotd.EmbedFooter(embed, postFooter)
+		return out.Post(channelID, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}})
+	})
 }
 
-type WordData struct {
-	Word     string    `json:"word"`
-	Meanings []Meaning `json:"meanings"`
+// dateFooter stamps a non-empty date into the embed's footer, ahead of the
+// source credit if there is one.
+func dateFooter(embed *discordgo.MessageEmbed, date string) {
+	if date == "" {
+		return
+	}
+	text := "— " + date
+	if embed.Footer != nil {
+		text += " · " + embed.Footer.Text
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: text}
 }
 
-// ---------------------------
-// Config
-// ---------------------------
+// Discord allows at most 10 embeds, and 6000 characters of embed text, per
+// message.
+const (
+	maxEmbedsPerMessage = 10
+	maxEmbedsTextLen    = 6000
+)
 
-type Config struct {
-	Token     string
-	GuildID   string // optional; if empty, registers globally
-	ChannelID string // required for scheduled posting
-	TZ        string // IANA timezone, e.g. "America/New_York"
-	PostAt    string // HH:MM 24h local in TZ
+// sendWOTDs posts several words (WORDS_PER_POST) as one message with a
+// section or embed per word, split into more messages only when Discord's
+// limits require it. A single word goes through sendWOTD, card and all.
+func sendWOTDs(out poster, channelID string, words []wotd.WordData, plain bool, date string) error {
+	if len(words) == 1 {
+		return sendWOTD(out, channelID, words[0], plain, date)
+	}
+	if plain {
+		msgs := wotd.FormatWOTDs(words, date)
+		msgs[len(msgs)-1] = wotd.WithFooter(msgs[len(msgs)-1], postFooter)
+		for _, msg := range msgs {
+			if err := retryRateLimited(channelID, func() error {
+				return out.Post(channelID, &discordgo.MessageSend{Content: msg})
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var embeds []*discordgo.MessageEmbed
+	for n, w := range words {
+		embed := wotd.BuildWOTDEmbed(w)
+		if n > 0 {
+			embed.Author = nil // the header leads the first embed only
+		}
+		embeds = append(embeds, embed)
+	}
+	return sendEmbeds(out, channelID, embeds, date)
 }
 
-func loadConfig() Config {
-	_ = godotenv.Load() // ok if .env missing
-	cfg := Config{
-		Token:     os.Getenv("DISCORD_TOKEN"),
-		GuildID:   os.Getenv("GUILD_ID"),
-		ChannelID: os.Getenv("CHANNEL_ID"),
-		TZ:        os.Getenv("TZ"),
-		PostAt:    os.Getenv("POST_AT"),
+// sendEmbeds posts embeds in as few messages as Discord's limits allow, with
+// the date and postFooter under the last one.
+func sendEmbeds(out poster, channelID string, embeds []*discordgo.MessageEmbed, date string) error {
+	dateFooter(embeds[len(embeds)-1], date)
+	wotd.EmbedFooter(embeds[len(embeds)-1], postFooter)
+	for _, g := range groupEmbeds(embeds) {
+		if err := retryRateLimited(channelID, func() error {
+			return out.Post(channelID, &discordgo.MessageSend{Embeds: g})
+		}); err != nil {
+			return err
+		}
 	}
-	return cfg
+	return nil
 }
 
-// ---------------------------
-// Word helpers
-// ---------------------------
-
-func fetchRandomWord() (string, error) {
-	resp, err := http.Get("https://random-word-api.herokuapp.com/word?number=1")
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("random word api status %d", resp.StatusCode)
-	}
-	var words RandomWordResponse
-	if err := json.NewDecoder(resp.Body).Decode(&words); err != nil {
-		return "", err
-	}
-	if len(words) == 0 {
-		return "", fmt.Errorf("no word returned")
+// groupEmbeds splits embeds, in order, into as few messages' worth as
+// Discord's per-message embed count and text limits allow.
+func groupEmbeds(embeds []*discordgo.MessageEmbed) [][]*discordgo.MessageEmbed {
+	var groups [][]*discordgo.MessageEmbed
+	var cur []*discordgo.MessageEmbed
+	size := 0
+	for _, embed := range embeds {
+		l := embedTextLen(embed)
+		if len(cur) == maxEmbedsPerMessage || len(cur) > 0 && size+l > maxEmbedsTextLen {
+			groups, cur, size = append(groups, cur), nil, 0
+		}
+		cur, size = append(cur, embed), size+l
 	}
-	return words[0], nil
+	return append(groups, cur)
 }
 
-func fetchDefinition(word string) (string, string, error) {
-	url := fmt.Sprintf("https://api.dictionaryapi.dev/api/v2/entries/en/%s", word)
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", "", err
+// embedTextLen counts the characters Discord holds against the 6000 limit.
+func embedTextLen(e *discordgo.MessageEmbed) int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	if e.Author != nil {
+		n += utf8.RuneCountInString(e.Author.Name)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return word, "", fmt.Errorf("dictionaryapi status %d", resp.StatusCode)
+	if e.Footer != nil {
+		n += utf8.RuneCountInString(e.Footer.Text)
 	}
-	var data []WordData
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", "", err
+	for _, f := range e.Fields {
+		n += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
 	}
-	if len(data) == 0 || len(data[0].Meanings) == 0 || len(data[0].Meanings[0].Definitions) == 0 {
-		return word, "", fmt.Errorf("no definition for %s", word)
+	return n
+}
+
+// retryRateLimited runs send and, if Discord answers 429, waits the
+// indicated RetryAfter and tries once more.
+func retryRateLimited(channelID string, send func() error) error {
+	err := send()
+	var rle *discordgo.RateLimitError
+	if !errors.As(err, &rle) || rle.RateLimit == nil || rle.TooManyRequests == nil {
+		return err
 	}
-	w := data[0].Word
-	pos := data[0].Meanings[0].PartOfSpeech
-	def := data[0].Meanings[0].Definitions[0].Definition
-	return w, fmt.Sprintf("%s — %s", italics(pos), def), nil
+	slog.Warn("[discord] rate limited, retrying", "channel", channelID, "retry_after", rle.RetryAfter)
+	time.Sleep(rle.RetryAfter)
+	return send()
 }
 
-func italics(s string) string {
-	if s == "" {
-		return ""
+// wotdResponse is the interaction equivalent of sendWOTD.
+func wotdResponse(w wotd.WordData, plain bool) *discordgo.InteractionResponseData {
+	if plain || w.Word == "" {
+		return &discordgo.InteractionResponseData{Content: wotd.FormatWOTD(w)}
 	}
-	return fmt.Sprintf("*(%s)*", s)
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{wotd.BuildWOTDEmbed(w)}}
 }
 
-// Try up to N random words until one has a definition.
-func getWOTD(retries int) (string, error) {
-	for i := 0; i < retries; i++ {
-		word, err := fetchRandomWord()
-		if err != nil {
-			continue
+// wotdResponses is the interaction equivalent of sendWOTDs, without the
+// footer: the reply, then any follow-ups Discord's limits call for.
+func wotdResponses(words []wotd.WordData, plain bool) []*discordgo.InteractionResponseData {
+	if len(words) == 1 {
+		return []*discordgo.InteractionResponseData{wotdResponse(words[0], plain)}
+	}
+	var out []*discordgo.InteractionResponseData
+	if plain {
+		for _, msg := range wotd.FormatWOTDs(words, "") {
+			out = append(out, &discordgo.InteractionResponseData{Content: msg})
 		}
-		w, def, err := fetchDefinition(word)
-		if err == nil {
-			return fmt.Sprintf("📖 Word of the Day:\n**%s** %s", strings.Title(w), def), nil
+		return out
+	}
+	var embeds []*discordgo.MessageEmbed
+	for n, w := range words {
+		embed := wotd.BuildWOTDEmbed(w)
+		if n > 0 {
+			embed.Author = nil // the header leads the first embed only
 		}
+		embeds = append(embeds, embed)
 	}
-	// fallback: last fetched word without def
-	word, err := fetchRandomWord()
-	if err != nil {
-		return "⚠️ Could not fetch a Word of the Day right now.", nil
+	for _, g := range groupEmbeds(embeds) {
+		out = append(out, &discordgo.InteractionResponseData{Embeds: g})
 	}
-	return fmt.Sprintf("📖 Word of the Day:\n**%s**\n(No definition found)", strings.Title(word)), nil
+	return out
 }
 
 // ---------------------------
-// Scheduler
+// main (slash commands + scheduler)
 // ---------------------------
 
-func scheduleDaily(s *discordgo.Session, channelID, tz, postAt string) {
-	if channelID == "" || tz == "" || postAt == "" {
-		log.Println("[scheduler] skipped (CHANNEL_ID/TZ/POST_AT not fully set)")
-		return
+// bot bundles the session and state shared by interaction handlers and
+// the scheduler.
+type bot struct {
+	s     *discordgo.Session
+	cfg   Config
+	hist  *History
+	state *State
+	store *Store
+
+	started time.Time // process start, for /stats
+
+	reload chan struct{} // signals the scheduler that guild configs changed
+	again  *clickLimiter
+	pages  *definePages
+
+	wotdLimit *clickLimiter  // per-user /wotd cooldown; nil when WOTD_COOLDOWN_SECONDS is 0
+	webhook   *webhookPoster // scheduled env posts go here; nil unless WEBHOOK_URL is set
+}
+
+func newBot(s *discordgo.Session, cfg Config, hist *History, state *State, store *Store) *bot {
+	b := &bot{
+		s:       s,
+		cfg:     cfg,
+		hist:    hist,
+		state:   state,
+		store:   store,
+		started: time.Now(),
+		reload:  make(chan struct{}, 1),
+		again:   newClickLimiter(againCooldown),
+		pages:   newDefinePages(definePagesTTL),
 	}
-	loc, err := time.LoadLocation(tz)
-	if err != nil {
-		log.Printf("[scheduler] invalid TZ %q: %v\n", tz, err)
-		return
+	if cfg.WOTDCooldown > 0 {
+		b.wotdLimit = newClickLimiter(cfg.WOTDCooldown)
 	}
-	parseHM := func(hm string) (int, int, error) {
-		var h, m int
-		_, err := fmt.Sscanf(hm, "%d:%d", &h, &m)
-		return h, m, err
+	if cfg.WebhookURL != "" {
+		b.webhook, _ = newWebhookPoster(s, cfg) // checked by Validate
 	}
-	nextRun := func(now time.Time) (time.Time, error) {
-		h, m, err := parseHM(postAt)
-		if err != nil {
-			return time.Time{}, err
-		}
-		t := time.Date(now.Year(), now.Month(), now.Day(), h, m, 0, 0, loc)
-		if !t.After(now) {
-			t = t.Add(24 * time.Hour)
+	return b
+}
+
+func main() {
+	cfg := loadConfig()
+	setupLogging(cfg)
+	for _, warning := range cfg.envWarnings {
+		slog.Warn("[config] " + warning)
+	}
+	if cfg.EnvFile != "" {
+		slog.Debug("[config] loaded env file", "path", cfg.EnvFile)
+	}
+	if err := cfg.Validate(); err != nil {
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			slog.Error("[config] " + problem)
 		}
-		return t, nil
+		os.Exit(1)
 	}
-	go func() {
-		for {
-			now := time.Now().In(loc)
-			next, err := nextRun(now)
-			if err != nil {
-				log.Printf("[scheduler] bad POST_AT: %v\n", err)
-				return
-			}
-			log.Printf("[scheduler] Next WOTD at %s", next.Format(time.RFC1123))
-			time.Sleep(time.Until(next))
-			msg, _ := getWOTD(5)
-			if _, err := s.ChannelMessageSend(channelID, msg); err != nil {
-				log.Printf("[scheduler] send failed: %v\n", err)
-			}
+
+	if problem := cfg.dateLayoutProblem(); problem != "" && cfg.ShowDate {
+		slog.Warn("[config] DATE_LAYOUT "+problem, "layout", cfg.DateLayout, "sample", time.Now().Format(cfg.DateLayout))
+	}
+
+	if cfg.dev() {
+		// Scheduled posts go to the test channel only; see bot.guildConfigs.
+		slog.Warn("[config] dev environment, scheduled posts go to TEST_CHANNEL_ID only", "channel", cfg.TestChannelID)
+		cfg.ChannelIDs = []string{cfg.TestChannelID}
+		cfg.WebhookURL = ""
+	} else if cfg.WebhookURL != "" {
+		if len(cfg.ChannelIDs) > 0 {
+			slog.Warn("[config] WEBHOOK_URL is set, scheduled posts go to the webhook instead of CHANNEL_ID", "channels", strings.Join(cfg.ChannelIDs, ","))
 		}
-	}()
-}
+		cfg.ChannelIDs = []string{webhookChannel}
+	}
 
-// ---------------------------
-// main (slash command + scheduler)
-// ---------------------------
+	wotd.HTTPClient.Timeout = cfg.HTTPTimeout
+	wotd.HTTPAttempts = cfg.HTTPRetries
+	wotd.UserAgent = cfg.UserAgent
+	d, _ := wotd.ParseDifficulty(cfg.Difficulty) // checked by Validate
+	wotd.DefaultPrefs = wotd.NewPrefs(cfg.Lang, d, cfg.MinLength, cfg.MaxLength)
+	wotd.AllowNonAlpha = cfg.AllowNonAlpha
+	wotd.RequireDefinition = cfg.RequireDefinition
+	wotd.MinDefLength = cfg.MinDefLength
+	renderCards = cfg.RenderCard
+	postFooter = cfg.PostFooter
+	multiEmbed = cfg.MultiEmbed
+	if cfg.RenderCard && cfg.SpoilerDefinition {
+		slog.Warn("[card] RENDER_CARD would reveal SPOILER_DEFINITION definitions, sending posts without the card")
+		renderCards = false
+	}
+	wotd.Formatting = wotd.FormatOptions{AllPOS: cfg.AllPOS, Emoji: cfg.Emoji, Header: cfg.Header, Fields: wotd.ParseFields(cfg.Fields), Color: cfg.EmbedColor, POSEmoji: cfg.POSEmoji}
+	wotd.Definitions = wotd.NewDefCache(cfg.CacheSize, cfg.CacheTTL)
+	wotd.Breakers = wotd.NewBreakers(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	wotd.Misses = wotd.NewMissRate(cfg.MissWarnWindow, float64(cfg.MissWarnPercent))
+	if apis := wotd.RandomWordAPIsFromNames(cfg.RandomWordAPIs); len(apis) > 0 {
+		wotd.RandomWordAPIs = apis
+	}
+	if ps := wotd.ProvidersFromNames(cfg.Providers); len(ps) > 0 {
+		wotd.Providers = ps
+	}
 
-func main() {
-	cfg := loadConfig()
-	if cfg.Token == "" {
-		log.Fatal("DISCORD_TOKEN is required")
+	if cfg.BlocklistPath != "" {
+		var err error
+		if wotd.Blocked, err = wotd.LoadWordSet(cfg.BlocklistPath); err != nil {
+			fatal("cannot load blocklist", "path", cfg.BlocklistPath, "err", err)
+		}
+		slog.Info("[blocklist] loaded", "words", len(wotd.Blocked))
+	}
+	if cfg.WordSource == "file" {
+		fs, err := wotd.LoadFileSource(cfg.WordlistPath, cfg.WordlistShuffle)
+		if err != nil {
+			fatal("cannot load word list", "path", cfg.WordlistPath, "err", err)
+		}
+		slog.Info("[wordlist] loaded", "words", fs.Len(), "shuffle", cfg.WordlistShuffle, "deterministic", cfg.Deterministic)
+		wotd.Source = fs
+		if cfg.Deterministic {
+			wotd.Source = wotd.NewDeterministicSource(fs, cfg.location())
+		}
 	}
 
 	s, err := discordgo.New("Bot " + cfg.Token)
 	if err != nil {
-		log.Fatal(err)
+		fatal("cannot create session", "err", err)
 	}
 
-	// Slash command handler
-	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		if i.Type != discordgo.InteractionApplicationCommand {
-			return
-		}
-		switch i.ApplicationCommandData().Name {
-		case "wotd":
-			msg, _ := getWOTD(5)
-			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{Content: msg},
-			})
+	// Background work (health server, scheduler) stops when ctx is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	var h health
+	h.track(s)
+	serveHealth(ctx, &wg, cfg.HealthPort, &h)
+
+	store, err := openStore(cfg.DBPath)
+	if err != nil {
+		fatal("cannot open database", "path", cfg.DBPath, "err", err)
+	}
+	defer store.Close()
+
+	if err := importHistoryFile(store, cfg.HistoryPath, cfg.HistorySize); err != nil {
+		slog.Error("[history] could not import old history file", "path", cfg.HistoryPath, "err", err)
+	}
+	if err := importStateFile(store, cfg.StatePath); err != nil {
+		slog.Error("[state] could not import old state file", "path", cfg.StatePath, "err", err)
+	}
+	hist, err := loadHistory(store, cfg.HistorySize)
+	if err != nil {
+		slog.Error("[history] could not load", "err", err)
+	}
+	state, err := loadState(store)
+	if err != nil {
+		slog.Error("[state] could not load", "err", err)
+	}
+
+	b := newBot(s, cfg, hist, state, store)
+	if b.webhook != nil {
+		if _, err := s.WebhookWithToken(b.webhook.id, b.webhook.token); err != nil {
+			slog.Error("[webhook] could not look up WEBHOOK_URL, scheduled posts will fail", "err", err)
 		}
+	}
+	s.AddHandler(b.onInteraction)
+	// discordgo waits out most 429s itself; make those visible too.
+	s.AddHandler(func(_ *discordgo.Session, rl *discordgo.RateLimit) {
+		slog.Warn("[discord] rate limited", "url", rl.URL, "retry_after", rl.RetryAfter)
 	})
 
 	if err := s.Open(); err != nil {
-		log.Fatal(err)
+		fatal("cannot open gateway connection", "err", err)
 	}
 	defer s.Close()
 
-	// Register /wotd (guild if provided, else global)
-	cmd := &discordgo.ApplicationCommand{Name: "wotd", Description: "Get a random Word of the Day"}
+	// Register slash commands (in each guild if provided, else global)
 	appID := s.State.User.ID
-	if _, err := s.ApplicationCommandCreate(appID, cfg.GuildID, cmd); err != nil {
-		log.Fatalf("cannot create command: %v", err)
+	guilds := cfg.GuildIDs
+	if len(guilds) == 0 {
+		guilds = []string{""}
 	}
+	created := map[string][]*discordgo.ApplicationCommand{}
+	localizeCommands(commands)
+	for _, guildID := range guilds {
+		for _, cmd := range commands {
+			c, err := createCommand(ctx, s, appID, guildID, cmd)
+			if err != nil {
+				fatal("cannot create command", "command", cmd.Name, "guild", guildID, "err", err)
+			}
+			created[guildID] = append(created[guildID], c)
+		}
+	}
+	h.ready.Store(true)
 
-	// Start scheduler (only if env vars present)
-	scheduleDaily(s, cfg.ChannelID, cfg.TZ, cfg.PostAt)
+	// Start scheduler (guild configs, else env vars)
+	if cfg.DryRun {
+		slog.Warn("[dry-run] scheduled posts will only be logged")
+	}
+	if cfg.Interval > 0 {
+		b.scheduleInterval(ctx, &wg)
+	} else {
+		b.scheduleDaily(ctx, &wg)
+	}
+	b.scheduleDigest(ctx, &wg)
 
-	log.Println("Bot running. Press CTRL+C to exit.")
+	slog.Info("Bot running. Press CTRL+C to exit.")
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
-	log.Println("Shutting down…")
+	slog.Info("Shutting down…")
+	cancel()
+	wg.Wait()
+	if cfg.CleanupCommands {
+		for guildID, cmds := range created {
+			deleteCommands(s, appID, guildID, cmds)
+		}
+	}
+}
+
+// Attempts and first backoff for registering a command; Discord's API has
+// the odd 5xx hiccup, which shouldn't stop the bot from starting.
+const (
+	registerAttempts = 4
+	registerBackoff  = time.Second
+)
+
+// createCommand registers cmd, retrying network errors, 429s and 5xx with
+// exponential backoff. Other API errors (a malformed command) fail at once.
+func createCommand(ctx context.Context, s *discordgo.Session, appID, guildID string, cmd *discordgo.ApplicationCommand) (*discordgo.ApplicationCommand, error) {
+	delay := registerBackoff
+	for attempt := 1; ; attempt++ {
+		c, err := s.ApplicationCommandCreate(appID, guildID, cmd)
+		var rest *discordgo.RESTError
+		transient := !errors.As(err, &rest) || rest.Response == nil ||
+			rest.Response.StatusCode == http.StatusTooManyRequests || rest.Response.StatusCode >= 500
+		if err == nil || !transient || attempt == registerAttempts {
+			return c, err
+		}
+		slog.Warn("[commands] registration failed, retrying", "command", cmd.Name, "guild", guildID, "attempt", attempt, "in", delay, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// deleteCommands removes the commands registered at startup, so changed or
+// renamed commands don't linger between development runs.
+func deleteCommands(s *discordgo.Session, appID, guildID string, cmds []*discordgo.ApplicationCommand) {
+	for _, c := range cmds {
+		if err := s.ApplicationCommandDelete(appID, guildID, c.ID); err != nil {
+			slog.Error("[commands] delete failed", "command", c.Name, "guild", guildID, "err", err)
+			continue
+		}
+		slog.Info("[commands] deleted", "command", c.Name, "guild", guildID)
+	}
 }
@@ -0,0 +1,59 @@
+package wotd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// ---------------------------
+// Blocklist
+// ---------------------------
+
+// WordSet is a case-insensitive set of exact words.
+type WordSet map[string]bool
+
+func (ws WordSet) Has(word string) bool {
+	return ws[strings.ToLower(strings.TrimSpace(word))]
+}
+
+// Words GetWOTD never picks; loaded from BLOCKLIST_PATH in main.
+var Blocked WordSet
+
+// LoadWordSet reads one word per line, ignoring blank lines and # comments.
+func LoadWordSet(path string) (WordSet, error) {
+	words, err := LoadWordList(path)
+	return toWordSet(words), err
+}
+
+func toWordSet(words []string) WordSet {
+	ws := WordSet{}
+	for _, w := range words {
+		ws[w] = true
+	}
+	return ws
+}
+
+// LoadWordList is LoadWordSet keeping file order and duplicates.
+func LoadWordList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readWordList(f)
+}
+
+func readWordList(r io.Reader) ([]string, error) {
+	var words []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, sc.Err()
+}
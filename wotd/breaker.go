@@ -0,0 +1,143 @@
+package wotd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ---------------------------
+// Circuit breaker (per definition provider)
+// ---------------------------
+
+// errBreakerOpen is returned, without asking the provider, while its
+// breaker is open.
+var errBreakerOpen = errors.New("circuit breaker open")
+
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // calls go through
+	breakerOpen                         // calls fail fast until the cooldown ends
+	breakerHalfOpen                     // one probe call decides whether to close again
+)
+
+// breaker opens after threshold consecutive failures, fails calls fast for
+// cooldown, then lets a single probe through: success closes it, failure
+// opens it for another cooldown. A nil breaker allows everything.
+type breaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time // time.Now; tests swap it
+
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool // a half-open probe is in flight
+}
+
+func newBreaker(name string, threshold int, cooldown time.Duration) *breaker {
+	return &breaker{name: name, threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may go ahead. Every allowed call must be
+// followed by Record, or by Abandon if the caller gave up on it.
+func (b *breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		fallthrough
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Record counts the outcome of an allowed call. A missing word is a healthy
+// answer, and a cancelled call says nothing about the provider either way.
+func (b *breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	switch {
+	case errors.Is(err, context.Canceled):
+		return
+	case err == nil || errors.Is(err, ErrNoDefinition):
+		if b.state != breakerClosed {
+			slog.Info("[breaker] closed, provider recovered", "provider", b.name)
+		}
+		b.state, b.failures = breakerClosed, 0
+		return
+	}
+	b.failures++
+	if b.state == breakerOpen {
+		return // a call that started before the breaker opened
+	}
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		slog.Warn("[breaker] open, skipping provider", "provider", b.name, "failures", b.failures, "cooldown", b.cooldown)
+		b.state, b.openedAt = breakerOpen, b.now()
+	}
+}
+
+// Abandon ends an allowed call without counting it either way, for a lookup
+// cut short by the caller's own context rather than by the provider.
+func (b *breaker) Abandon() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// BreakerSet holds a breaker per provider name, created on first use.
+type BreakerSet struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	byName    map[string]*breaker
+}
+
+// NewBreakers opens a provider's breaker after threshold consecutive
+// failures, for cooldown. A threshold of 0 or less disables them (nil).
+func NewBreakers(threshold int, cooldown time.Duration) *BreakerSet {
+	if threshold <= 0 {
+		return nil
+	}
+	return &BreakerSet{threshold: threshold, cooldown: cooldown, byName: map[string]*breaker{}}
+}
+
+func (bs *BreakerSet) get(name string) *breaker {
+	if bs == nil {
+		return nil
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b, ok := bs.byName[name]
+	if !ok {
+		b = newBreaker(name, bs.threshold, bs.cooldown)
+		bs.byName[name] = b
+	}
+	return b
+}
+
+// Breakers guards each definition provider; set from config in main.
+var Breakers = NewBreakers(5, time.Minute)
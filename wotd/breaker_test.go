@@ -0,0 +1,168 @@
+package wotd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("503")
+
+// testBreaker returns a breaker on a clock the test moves by hand.
+func testBreaker(threshold int, cooldown time.Duration) (*breaker, *time.Time) {
+	now := time.Date(2024, 3, 7, 9, 0, 0, 0, time.UTC)
+	b := newBreaker("test", threshold, cooldown)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b, _ := testBreaker(3, time.Minute)
+	for i := 0; i < 2; i++ {
+		if !b.Allow() {
+			t.Fatalf("call %d refused while closed", i+1)
+		}
+		b.Record(errDown)
+	}
+	if b.state != breakerClosed {
+		t.Fatalf("state = %d after 2 failures, want closed", b.state)
+	}
+	b.Allow()
+	b.Record(errDown)
+	if b.state != breakerOpen {
+		t.Fatalf("state = %d after 3 failures, want open", b.state)
+	}
+	if b.Allow() {
+		t.Error("call allowed while open")
+	}
+}
+
+func TestBreakerSuccessResetsCount(t *testing.T) {
+	b, _ := testBreaker(2, time.Minute)
+	b.Allow()
+	b.Record(errDown)
+	b.Allow()
+	b.Record(ErrNoDefinition) // the provider answered
+	b.Allow()
+	b.Record(errDown)
+	if b.state != breakerClosed {
+		t.Errorf("state = %d, want closed: failures weren't consecutive", b.state)
+	}
+	b.Allow()
+	b.Record(context.Canceled)
+	if b.failures != 1 {
+		t.Errorf("failures = %d after a cancelled call, want 1", b.failures)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		probe     error
+		wantState breakerState
+		wantAllow bool
+	}{
+		{"probe succeeds", nil, breakerClosed, true},
+		{"probe fails", errDown, breakerOpen, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now := testBreaker(1, time.Minute)
+			b.Allow()
+			b.Record(errDown)
+
+			*now = now.Add(59 * time.Second)
+			if b.Allow() {
+				t.Fatal("call allowed before the cooldown ended")
+			}
+			*now = now.Add(time.Second)
+			if !b.Allow() {
+				t.Fatal("probe refused after the cooldown")
+			}
+			if b.state != breakerHalfOpen {
+				t.Fatalf("state = %d during the probe, want half-open", b.state)
+			}
+			if b.Allow() {
+				t.Fatal("second call allowed while the probe is in flight")
+			}
+			b.Record(tt.probe)
+			if b.state != tt.wantState {
+				t.Errorf("state = %d after the probe, want %d", b.state, tt.wantState)
+			}
+			if got := b.Allow(); got != tt.wantAllow {
+				t.Errorf("Allow() after the probe = %v, want %v", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestNewBreakersDisabled(t *testing.T) {
+	bs := NewBreakers(0, time.Minute)
+	if bs != nil {
+		t.Fatal("NewBreakers(0) should disable breakers")
+	}
+	br := bs.get("dictionaryapi")
+	for i := 0; i < 10; i++ {
+		if !br.Allow() {
+			t.Fatal("disabled breaker refused a call")
+		}
+		br.Record(errDown)
+	}
+}
+
+// downProvider fails every lookup and counts them.
+type downProvider struct{ calls int }
+
+func (p *downProvider) Name() string { return "down" }
+
+func (p *downProvider) Define(context.Context, string, string) (WordData, error) {
+	p.calls++
+	return WordData{}, errDown
+}
+
+func TestLookupDefinitionSkipsOpenBreaker(t *testing.T) {
+	down, echo := &downProvider{}, &echoProvider{}
+	prevProviders, prevBreakers := Providers, Breakers
+	Providers, Breakers = []DefinitionProvider{down, echo}, NewBreakers(2, time.Minute)
+	t.Cleanup(func() { Providers, Breakers = prevProviders, prevBreakers })
+
+	for i := 0; i < 4; i++ {
+		data, err := lookupDefinition(context.Background(), "tenacity", "en")
+		if err != nil {
+			t.Fatalf("lookup %d: %v", i+1, err)
+		}
+		if data.Source != "echo" {
+			t.Errorf("lookup %d: source = %q, want the fallback", i+1, data.Source)
+		}
+	}
+	if down.calls != 2 {
+		t.Errorf("failing provider asked %d times, want 2 (then skipped)", down.calls)
+	}
+}
+
+// stalledProvider never answers before the caller's context is done.
+type stalledProvider struct{}
+
+func (stalledProvider) Name() string { return "stalled" }
+
+func (stalledProvider) Define(ctx context.Context, _, _ string) (WordData, error) {
+	<-ctx.Done()
+	return WordData{}, ctx.Err()
+}
+
+func TestLookupDefinitionExpiredContextKeepsBreakerClosed(t *testing.T) {
+	prevProviders, prevBreakers := Providers, Breakers
+	Providers, Breakers = []DefinitionProvider{stalledProvider{}}, NewBreakers(1, time.Minute)
+	t.Cleanup(func() { Providers, Breakers = prevProviders, prevBreakers })
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := lookupDefinition(ctx, "tenacity", "en"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("lookup err = %v, want the deadline", err)
+	}
+	br := Breakers.get("stalled")
+	if br.state != breakerClosed || br.failures != 0 {
+		t.Errorf("breaker state %v with %d failures after an expired lookup, want closed with none", br.state, br.failures)
+	}
+}
@@ -0,0 +1,71 @@
+package wotd
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// ---------------------------
+// Definition cache (LRU)
+// ---------------------------
+
+// DefCache is a size-bounded LRU of successful lookups with an optional TTL.
+type DefCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration // 0 = entries never expire
+	order *list.List    // front = most recently used
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	data    WordData
+	expires time.Time
+}
+
+func NewDefCache(size int, ttl time.Duration) *DefCache {
+	return &DefCache{size: size, ttl: ttl, order: list.New(), items: map[string]*list.Element{}}
+}
+
+func (c *DefCache) Get(key string) (WordData, bool) {
+	if c == nil || c.size <= 0 {
+		return WordData{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return WordData{}, false
+	}
+	e := el.Value.(*cacheEntry)
+	if c.ttl > 0 && time.Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		return WordData{}, false
+	}
+	c.order.MoveToFront(el)
+	return e.data, true
+}
+
+func (c *DefCache) Put(key string, data WordData) {
+	if c == nil || c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*cacheEntry)
+		e.data, e.expires = data, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, data: data, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
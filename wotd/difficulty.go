@@ -0,0 +1,126 @@
+package wotd
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ---------------------------
+// Difficulty tiers
+// ---------------------------
+
+// Bundled list of everyday English words; a word on it counts as common.
+//
+//go:embed common_words.txt
+var commonWordsTxt string
+
+var (
+	commonWordList = mustWordList(commonWordsTxt)
+	commonWords    = toWordSet(commonWordList)
+	commonWordRank = rankWords(commonWordList)
+)
+
+func mustWordList(s string) []string {
+	words, err := readWordList(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return words
+}
+
+// rankWords maps each word to its position in the list, first one 0.
+func rankWords(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for i, w := range words {
+		if _, ok := ranks[w]; !ok {
+			ranks[w] = i
+		}
+	}
+	return ranks
+}
+
+// Difficulty narrows which random words GetWOTD accepts.
+type Difficulty int
+
+const (
+	anyDifficulty Difficulty = iota
+	easy                     // short, common words
+	medium                   // mid-length words
+	hard                     // long words not on the common list
+)
+
+func ParseDifficulty(v string) (Difficulty, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "":
+		return anyDifficulty, nil
+	case "easy":
+		return easy, nil
+	case "medium":
+		return medium, nil
+	case "hard":
+		return hard, nil
+	}
+	return anyDifficulty, fmt.Errorf("DIFFICULTY %q must be easy, medium or hard", v)
+}
+
+// lengths is the word length range of the tier; 0 means unbounded. It is
+// only used when WORD_MIN_LENGTH/WORD_MAX_LENGTH aren't set.
+func (d Difficulty) lengths() (min, max int) {
+	switch d {
+	case easy:
+		return 3, 6
+	case medium:
+		return 5, 9
+	case hard:
+		return 8, 0
+	}
+	return 0, 0
+}
+
+// fits reports whether word belongs in the tier. Length is already handled
+// by fetchRandomWord, so this mostly checks frequency. The common list is
+// English, so in any other lang only hard's length bound applies.
+func (d Difficulty) fits(word, lang string) bool {
+	word = strings.ToLower(word)
+	english := lang == "en"
+	switch d {
+	case easy:
+		return !english || commonWords.Has(word)
+	case hard:
+		return (!english || !commonWords.Has(word)) && utf8.RuneCountInString(word) >= 8
+	}
+	return true
+}
+
+// ---------------------------
+// Rarity
+// ---------------------------
+
+// Words ranked above this on the common list are labeled "common", the rest
+// of the list "uncommon".
+const commonRankCutoff = 300
+
+// Rarity labels how frequent an English word is, from its rank on the
+// bundled common list: "common", "uncommon", or "rare" for words not on it.
+// Inflected forms are ranked by their base form, so "cats" is as common as
+// "cat".
+func Rarity(word string) string {
+	word = strings.ToLower(word)
+	rank, ok := commonWordRank[word]
+	if !ok {
+		for _, base := range baseForms(word, "en") {
+			if rank, ok = commonWordRank[base]; ok {
+				break
+			}
+		}
+	}
+	switch {
+	case !ok:
+		return "rare"
+	case rank < commonRankCutoff:
+		return "common"
+	}
+	return "uncommon"
+}
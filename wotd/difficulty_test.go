@@ -0,0 +1,35 @@
+package wotd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRarity(t *testing.T) {
+	tests := []struct{ word, want string }{
+		{"the", "common"},
+		{"House", "common"},
+		{"houses", "common"}, // ranked by its base form
+		{"cat", "uncommon"},
+		{"guessed", "uncommon"},
+		{"perspicacious", "rare"},
+		{"", "rare"},
+	}
+	for _, tt := range tests {
+		if got := Rarity(tt.word); got != tt.want {
+			t.Errorf("Rarity(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestGetWOTDEasyInSpanish(t *testing.T) {
+	echo := &echoProvider{}
+	prevSource, prevProviders, prevCache := Source, Providers, Definitions
+	Source, Providers, Definitions = &seqSource{"casa"}, []DefinitionProvider{echo}, nil
+	t.Cleanup(func() { Source, Providers, Definitions = prevSource, prevProviders, prevCache })
+
+	p := NewPrefs("es", easy, 0, 0)
+	if got, _ := GetWOTD(context.Background(), 1, NewSelector(p, nil, nil), p.Lang); got.Word != "casa" || len(echo.asked) != 1 {
+		t.Errorf("GetWOTD = %q after looking up %q, want casa looked up: the common list is English only", got.Word, echo.asked)
+	}
+}
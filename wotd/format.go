@@ -0,0 +1,610 @@
+package wotd
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ---------------------------
+// Formatting
+// ---------------------------
+
+// Primary is the main sense: the first definition of the first meaning.
+func (w WordData) Primary() (Meaning, Definition, bool) {
+	if len(w.Meanings) == 0 || len(w.Meanings[0].Definitions) == 0 {
+		return Meaning{}, Definition{}, false
+	}
+	return w.Meanings[0], w.Meanings[0].Definitions[0], true
+}
+
+// Pronunciation text. Entries with both e.g. a UK and a US phonetic show each,
+// labeled ("UK /ˈtɒmɑːtəʊ/ · US /təˈmeɪtoʊ/"); otherwise it's the top-level
+// phonetic, else the first phonetics entry with text.
+func (w WordData) pronunciation() string {
+	if p := dialectPhonetics(w.Phonetics); p != "" {
+		return p
+	}
+	if w.Phonetic != "" {
+		return w.Phonetic
+	}
+	for _, p := range w.Phonetics {
+		if p.Text != "" {
+			return p.Text
+		}
+	}
+	return ""
+}
+
+// dialectPhonetics labels the first phonetic with text of each dialect, in
+// API order. It returns "" unless at least two dialects differ, so words
+// with one known (or no known) dialect keep the single phonetic.
+func dialectPhonetics(ps []Phonetic) string {
+	var parts []string
+	texts := map[string]bool{}
+	seen := map[string]bool{}
+	for _, p := range ps {
+		d := dialect(p)
+		if p.Text == "" || d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		texts[p.Text] = true
+		parts = append(parts, d+" "+p.Text)
+	}
+	if len(parts) < 2 || len(texts) < 2 {
+		return ""
+	}
+	return strings.Join(parts, " · ")
+}
+
+// Dialect codes the dictionary API puts at the end of audio file names,
+// e.g. "…/tomato-uk.mp3".
+var audioDialects = map[string]string{"uk": "UK", "us": "US", "au": "AU", "ca": "CA"}
+
+// dialect guesses a phonetic's dialect from its audio URL, or "".
+func dialect(p Phonetic) string {
+	name := path.Base(strings.TrimSpace(p.Audio))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return ""
+	}
+	return audioDialects[strings.ToLower(name[i+1:])]
+}
+
+// audioURL is the first non-empty pronunciation audio link. Older entries use
+// protocol-relative URLs, which Discord won't link, so those get https.
+func (w WordData) audioURL() string {
+	for _, p := range w.Phonetics {
+		if a := strings.TrimSpace(p.Audio); a != "" {
+			if strings.HasPrefix(a, "//") {
+				a = "https:" + a
+			}
+			return a
+		}
+	}
+	return ""
+}
+
+// Heading renders the bold word followed by its pronunciation, if any.
+func Heading(w WordData) string {
+	if p := w.pronunciation(); p != "" && Formatting.show("phonetic") {
+		return fmt.Sprintf("**%s** %s", CapitalizeWord(w.Word), p)
+	}
+	return fmt.Sprintf("**%s**", CapitalizeWord(w.Word))
+}
+
+// Prefer the definition's own related words, fall back to the meaning's.
+func relatedWords(m Meaning, d Definition) (synonyms, antonyms []string) {
+	synonyms, antonyms = d.Synonyms, d.Antonyms
+	if len(synonyms) == 0 {
+		synonyms = m.Synonyms
+	}
+	if len(antonyms) == 0 {
+		antonyms = m.Antonyms
+	}
+	return capList(synonyms), capList(antonyms)
+}
+
+// FormatOptions are the formatter settings; set from config in main.
+type FormatOptions struct {
+	AllPOS   bool            // one line per part of speech instead of just the primary sense
+	Emoji    string          // leads the header
+	Header   string          // header text; empty drops the header, emoji included
+	Fields   map[string]bool // WOTD_FIELDS; nil shows every field
+	Color    int             // embed sidebar color
+	POSEmoji bool            // lead parts of speech with an emoji (POS_EMOJI)
+}
+
+// A calm blue, used unless EMBED_COLOR says otherwise.
+const DefaultEmbedColor = 0x3498DB
+
+var Formatting = FormatOptions{Emoji: "📖", Header: "Word of the Day", Color: DefaultEmbedColor}
+
+// Fields WOTD_FIELDS can select.
+var knownFields = []string{"phonetic", "pos", "definition", "example", "synonyms", "antonyms", "audio", "etymology", "rarity"}
+
+// ParseFields turns WOTD_FIELDS into a set, warning about unknown names.
+// An empty list means every field.
+func ParseFields(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	fields := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !slices.Contains(knownFields, name) {
+			slog.Warn("[config] unknown WOTD_FIELDS entry", "field", name, "known", strings.Join(knownFields, ","))
+			continue
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+func (o FormatOptions) show(field string) bool {
+	return o.Fields == nil || o.Fields[field]
+}
+
+// title is the message header, e.g. "📖 Word of the Day", or "" if disabled.
+func (o FormatOptions) title() string {
+	if o.Header == "" {
+		return ""
+	}
+	return strings.TrimSpace(o.Emoji + " " + o.Header)
+}
+
+// senseLines renders "*(pos)* — definition" for the primary sense, or for
+// the first definition of each distinct part of speech in all-POS mode.
+func senseLines(w WordData) []string {
+	meanings := w.Meanings[:1]
+	if Formatting.AllPOS {
+		meanings = w.Meanings
+	}
+	var lines []string
+	seen := map[string]bool{}
+	for _, m := range meanings {
+		if len(m.Definitions) == 0 || seen[m.PartOfSpeech] {
+			continue
+		}
+		seen[m.PartOfSpeech] = true
+		var parts []string
+		if Formatting.show("pos") && m.PartOfSpeech != "" {
+			parts = append(parts, POSLabel(m.PartOfSpeech))
+		}
+		if Formatting.show("definition") {
+			parts = append(parts, m.Definitions[0].Definition)
+		}
+		if len(parts) > 0 {
+			lines = append(lines, strings.Join(parts, " — "))
+		}
+	}
+	return lines
+}
+
+// FormatDefinition renders the primary sense as markdown lines:
+// part of speech and definition, example, synonyms, antonyms.
+func FormatDefinition(w WordData) string {
+	meaning, def, ok := w.Primary()
+	if !ok {
+		return "(No definition found)"
+	}
+	lines := senseLines(w)
+	if ex := FirstExample(meaning); ex != "" && Formatting.show("example") {
+		lines = append(lines, fmt.Sprintf("> *\"%s\"*", ex))
+	}
+	synonyms, antonyms := relatedWords(meaning, def)
+	if len(synonyms) > 0 && Formatting.show("synonyms") {
+		lines = append(lines, "Synonyms: "+strings.Join(synonyms, ", "))
+	}
+	if len(antonyms) > 0 && Formatting.show("antonyms") {
+		lines = append(lines, "Antonyms: "+strings.Join(antonyms, ", "))
+	}
+	if w.Etymology != "" && Formatting.show("etymology") {
+		lines = append(lines, "Origin: "+w.Etymology)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatWOTD renders a GetWOTD result as a plain-text message, within
+// Discord's length limit.
+func FormatWOTD(w WordData) string {
+	if w.Word == "" {
+		return "⚠️ Could not fetch a Word of the Day right now."
+	}
+	return FitMessage(messageHeader() + wotdSection(w))
+}
+
+// FormatWord is FormatWOTD without the header, for a word that isn't
+// presented as the Word of the Day.
+func FormatWord(w WordData) string {
+	if w.Word == "" {
+		return "⚠️ Could not fetch a word right now."
+	}
+	return FitMessage(wotdSection(w))
+}
+
+// messageHeader is the "📖 Word of the Day:" line of a plain-text post, or
+// "" when the header is off.
+func messageHeader() string {
+	if t := Formatting.title(); t != "" {
+		return t + ":\n"
+	}
+	return ""
+}
+
+// wotdSection is one word of a plain-text post, without the header.
+func wotdSection(w WordData) string {
+	if _, _, ok := w.Primary(); !ok {
+		return fmt.Sprintf("**%s**\n(No definition found)", CapitalizeWord(w.Word))
+	}
+	return fmt.Sprintf("%s %s", Heading(w), FormatDefinition(w)) + SourceCredit(w)
+}
+
+// SourceCredit is the line crediting the provider that defined w, for the
+// end of a plain-text message, or "" if unknown.
+func SourceCredit(w WordData) string {
+	if src := w.sourceLabel(); src != "" {
+		return "\n— via " + src
+	}
+	return ""
+}
+
+// FormatWOTDs renders several words as plain text: the header once, then a
+// section per word, packed into as few messages as Discord's length limit
+// allows. date (SHOW_DATE) goes at the very end. Each section is fitted with
+// whatever it carries (the header, the date) before it is packed, so no
+// message goes over the limit.
+func FormatWOTDs(words []WordData, date string) []string {
+	var msgs []string
+	cur := ""
+	for n, w := range words {
+		sec := wotdSection(w)
+		if n == 0 {
+			sec = messageHeader() + sec
+		}
+		if n == len(words)-1 {
+			sec = WithDate(sec, date)
+		}
+		sec = FitMessage(sec)
+		switch {
+		case n == 0:
+			cur = sec
+		case utf8.RuneCountInString(cur)+2+utf8.RuneCountInString(sec) > maxMessageLen:
+			msgs = append(msgs, FitMessage(cur))
+			cur = sec
+		default:
+			cur += "\n\n" + sec
+		}
+	}
+	return append(msgs, FitMessage(cur))
+}
+
+// BuildWOTDEmbed renders a GetWOTD result as a Discord embed.
+func BuildWOTDEmbed(w WordData) *discordgo.MessageEmbed {
+	embed := BuildWordEmbed(w)
+	if t := Formatting.title(); t != "" {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: t}
+	}
+	return embed
+}
+
+// BuildWordEmbed is BuildWOTDEmbed without the header.
+func BuildWordEmbed(w WordData) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{Title: CapitalizeWord(w.Word), Color: Formatting.Color}
+	meaning, def, ok := w.Primary()
+	if !ok {
+		embed.Description = "(No definition found)"
+		return embed
+	}
+	if p := w.pronunciation(); p != "" && Formatting.show("phonetic") {
+		embed.Title += " " + p
+	}
+	if Formatting.show("definition") {
+		embed.Description = def.Definition
+	}
+	if Formatting.AllPOS {
+		embed.Description = strings.Join(senseLines(w), "\n")
+	} else if meaning.PartOfSpeech != "" && Formatting.show("pos") {
+		value := meaning.PartOfSpeech
+		if e := posEmoji(value); e != "" && Formatting.POSEmoji {
+			value = e + " " + value
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Part of speech", Value: value, Inline: true})
+	}
+	if ex := FirstExample(meaning); ex != "" && Formatting.show("example") {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Example", Value: fmt.Sprintf("*\"%s\"*", ex)})
+	}
+	synonyms, antonyms := relatedWords(meaning, def)
+	if len(synonyms) > 0 && Formatting.show("synonyms") {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Synonyms", Value: strings.Join(synonyms, ", "), Inline: true})
+	}
+	if len(antonyms) > 0 && Formatting.show("antonyms") {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Antonyms", Value: strings.Join(antonyms, ", "), Inline: true})
+	}
+	if w.Etymology != "" && Formatting.show("etymology") {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Origin", Value: w.Etymology})
+	}
+	if a := w.audioURL(); a != "" && Formatting.show("audio") {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Audio", Value: fmt.Sprintf("[🔊 Pronunciation](%s)", a), Inline: true})
+	}
+	if w.Lang == "en" && Formatting.show("rarity") { // the frequency list is English only
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Rarity", Value: Rarity(w.Word), Inline: true})
+	}
+	if src := w.sourceLabel(); src != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "via " + src}
+	}
+	embed.Description = truncate(embed.Description, maxEmbedDescLen)
+	for _, f := range embed.Fields {
+		f.Value = truncate(f.Value, maxEmbedFieldLen)
+	}
+	return embed
+}
+
+// BuildMeaningEmbeds renders w as one embed per part of speech (MULTI_EMBED),
+// each listing that meaning's definitions. Past max embeds the last one
+// sums up the rest. The header and word lead the first embed and the source
+// credit closes the last. A word without a definition gets BuildWOTDEmbed.
+func BuildMeaningEmbeds(w WordData, max int) []*discordgo.MessageEmbed {
+	if _, _, ok := w.Primary(); !ok || max < 1 {
+		return []*discordgo.MessageEmbed{BuildWOTDEmbed(w)}
+	}
+	meanings, rest := w.Meanings, []Meaning(nil)
+	if len(meanings) > max {
+		meanings, rest = meanings[:max-1], meanings[max-1:]
+	}
+	var embeds []*discordgo.MessageEmbed
+	for _, m := range meanings {
+		embeds = append(embeds, &discordgo.MessageEmbed{
+			Title:       meaningTitle(m.PartOfSpeech),
+			Description: truncate(strings.Join(meaningLines(m), "\n"), maxEmbedDescLen),
+			Color:       Formatting.Color,
+		})
+	}
+	if len(rest) > 0 {
+		var names []string
+		for _, m := range rest {
+			names = append(names, meaningTitle(m.PartOfSpeech))
+		}
+		embeds = append(embeds, &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("…and %d more", len(rest)),
+			Description: truncate(strings.Join(names, ", "), maxEmbedDescLen),
+			Color:       Formatting.Color,
+		})
+	}
+	author := CapitalizeWord(w.Word)
+	if p := w.pronunciation(); p != "" && Formatting.show("phonetic") {
+		author += " " + p
+	}
+	if t := Formatting.title(); t != "" {
+		author = t + " · " + author
+	}
+	embeds[0].Author = &discordgo.MessageEmbedAuthor{Name: truncate(author, maxEmbedAuthorLen)}
+	if src := w.sourceLabel(); src != "" {
+		embeds[len(embeds)-1].Footer = &discordgo.MessageEmbedFooter{Text: "via " + src}
+	}
+	return embeds
+}
+
+// meaningTitle is a MULTI_EMBED embed title: the part of speech, after its
+// emoji with POS_EMOJI.
+func meaningTitle(pos string) string {
+	if pos == "" || spoilered(pos) { // embed titles don't render spoilers
+		return "Definitions"
+	}
+	if e := posEmoji(pos); e != "" && Formatting.POSEmoji {
+		return e + " " + pos
+	}
+	return pos
+}
+
+// meaningLines numbers a meaning's definitions, each followed by its
+// example.
+func meaningLines(m Meaning) []string {
+	var lines []string
+	for n, d := range m.Definitions {
+		if Formatting.show("definition") {
+			lines = append(lines, fmt.Sprintf("%d. %s", n+1, d.Definition))
+		}
+		if d.Example != "" && Formatting.show("example") {
+			lines = append(lines, fmt.Sprintf("*\"%s\"*", d.Example))
+		}
+	}
+	return lines
+}
+
+// Spoilered returns a copy of w with everything that could give the answer
+// away wrapped in Discord spoiler markup, so readers can guess before
+// revealing: definitions, examples, parts of speech, each synonym and
+// antonym, and the origin. Each text is cut to maxSpoilerLen before it is
+// wrapped, so the message limit never cuts off a closing "||". w itself may
+// be shared with the definition cache and is left alone.
+func Spoilered(w WordData) WordData {
+	meanings := make([]Meaning, len(w.Meanings))
+	for n, m := range w.Meanings {
+		defs := make([]Definition, len(m.Definitions))
+		for k, d := range m.Definitions {
+			d.Definition = spoiler(d.Definition)
+			d.Example = spoiler(d.Example)
+			d.Synonyms, d.Antonyms = spoilerAll(d.Synonyms), spoilerAll(d.Antonyms)
+			defs[k] = d
+		}
+		m.PartOfSpeech = spoiler(m.PartOfSpeech)
+		m.Synonyms, m.Antonyms = spoilerAll(m.Synonyms), spoilerAll(m.Antonyms)
+		m.Definitions = defs
+		meanings[n] = m
+	}
+	w.Meanings = meanings
+	w.Etymology = spoiler(w.Etymology)
+	return w
+}
+
+// Longest text Spoilered wraps; two of them plus the heading stay well
+// within a message.
+const maxSpoilerLen = 800
+
+// spoiler wraps non-empty s in spoiler markup, truncated first.
+func spoiler(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "||" + truncate(s, maxSpoilerLen) + "||"
+}
+
+func spoilerAll(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	out := make([]string, len(words))
+	for n, w := range words {
+		out[n] = spoiler(w)
+	}
+	return out
+}
+
+// spoilered reports whether s is wholly spoiler markup.
+func spoilered(s string) bool {
+	return len(s) >= 4 && strings.HasPrefix(s, "||") && strings.HasSuffix(s, "||")
+}
+
+// First non-empty example in the meaning, starting with the primary definition.
+func FirstExample(m Meaning) string {
+	for _, d := range m.Definitions {
+		if ex := strings.TrimSpace(d.Example); ex != "" {
+			return ex
+		}
+	}
+	return ""
+}
+
+// Max synonyms/antonyms shown per list.
+const maxRelated = 5
+
+func capList(words []string) []string {
+	if len(words) > maxRelated {
+		return words[:maxRelated]
+	}
+	return words
+}
+
+// CapitalizeWord uppercases the first rune and lowercases the rest, so
+// "o'clock" stays "O'clock" where strings.Title gave "O'Clock".
+func CapitalizeWord(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + strings.ToLower(s[size:])
+}
+
+var partOfSpeechEmoji = map[string]string{
+	"noun":         "🧱",
+	"verb":         "🏃",
+	"adjective":    "🎨",
+	"adverb":       "⚡",
+	"pronoun":      "👤",
+	"preposition":  "🧭",
+	"conjunction":  "🔗",
+	"interjection": "❗",
+}
+
+// posEmoji is the emoji for a part of speech, or "" for ones it doesn't know.
+func posEmoji(pos string) string {
+	return partOfSpeechEmoji[strings.ToLower(strings.TrimSpace(pos))]
+}
+
+// POSLabel renders a part of speech in italics, after its emoji when
+// POS_EMOJI is on.
+func POSLabel(pos string) string {
+	if e := posEmoji(pos); e != "" && Formatting.POSEmoji {
+		return e + " " + italics(pos)
+	}
+	return italics(pos)
+}
+
+func italics(s string) string {
+	if s == "" {
+		return ""
+	}
+	return fmt.Sprintf("*(%s)*", s)
+}
+
+// Discord rejects messages over 2000 characters, and embed text over these.
+const (
+	maxMessageLen     = 2000
+	maxEmbedDescLen   = 4096
+	maxEmbedFieldLen  = 1024
+	maxEmbedFooterLen = 2048
+	maxEmbedAuthorLen = 256
+)
+
+// FitMessage keeps msg within maxMessageLen. Synonym, antonym and origin
+// lines are the least important, so they go first; anything still too long
+// is cut with an ellipsis.
+func FitMessage(msg string) string {
+	if utf8.RuneCountInString(msg) <= maxMessageLen {
+		return msg
+	}
+	var kept []string
+	for _, line := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(line, "Synonyms: ") || strings.HasPrefix(line, "Antonyms: ") || strings.HasPrefix(line, "Origin: ") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return truncate(strings.Join(kept, "\n"), maxMessageLen)
+}
+
+// truncate cuts s to at most n runes, ending in "…" when shortened. A cut
+// inside a spoiler closes it, so the rest isn't shown in plain view.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	cut := strings.TrimRightFunc(string(r[:n-1]), unicode.IsSpace)
+	if strings.Count(cut, "||")%2 == 1 {
+		cut = strings.TrimRightFunc(string(r[:n-3]), unicode.IsSpace)
+		if strings.Count(cut, "||")%2 == 1 {
+			return cut + "…||"
+		}
+	}
+	return cut + "…"
+}
+
+// WithDate stamps a non-empty date (SHOW_DATE) below msg.
+func WithDate(msg, date string) string {
+	if date == "" {
+		return msg
+	}
+	return msg + "\n— " + date
+}
+
+// WithFooter adds footer (POST_FOOTER) on the lines below msg, cut short,
+// or left off, so the message stays within Discord's limit.
+func WithFooter(msg, footer string) string {
+	room := maxMessageLen - utf8.RuneCountInString(msg) - 1
+	if footer == "" || room < 1 {
+		return msg
+	}
+	return msg + "\n" + truncate(footer, room)
+}
+
+// EmbedFooter adds footer below whatever e's footer already says, within
+// Discord's footer limit.
+func EmbedFooter(e *discordgo.MessageEmbed, footer string) {
+	if footer == "" {
+		return
+	}
+	if e.Footer != nil {
+		footer = e.Footer.Text + "\n" + footer
+	}
+	e.Footer = &discordgo.MessageEmbedFooter{Text: truncate(footer, maxEmbedFooterLen)}
+}
@@ -0,0 +1,183 @@
+package wotd
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormatWOTDsFitsMessageLimit(t *testing.T) {
+	long := func(n int) string { return strings.Repeat("word ", n/5) }
+	word := func(name string) WordData {
+		return WordData{Word: name, Meanings: []Meaning{{
+			PartOfSpeech: "noun",
+			Definitions:  []Definition{{Definition: long(3000)}},
+		}}}
+	}
+	msgs := FormatWOTDs([]WordData{word("alpha"), word("bravo")}, "Monday, June 3")
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want one per oversized word", len(msgs))
+	}
+	for i, msg := range msgs {
+		if n := utf8.RuneCountInString(msg); n > maxMessageLen {
+			t.Errorf("message %d is %d runes, over the %d limit", i+1, n, maxMessageLen)
+		}
+	}
+}
+
+func TestFormatWOTDFitsMessageLimit(t *testing.T) {
+	long := func(n int) string { return strings.Repeat("word ", n/5) }
+	related := []string{long(60), long(60), long(60), long(60), long(60)}
+	tests := []struct {
+		name     string
+		def      string
+		wantDef  bool // definition survives intact
+		wantTail string
+	}{
+		{"drops related words first", long(1700), true, ""},
+		{"truncates oversized definition", long(3000), false, "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := WordData{Word: "verbose", Meanings: []Meaning{{
+				PartOfSpeech: "adjective",
+				Definitions:  []Definition{{Definition: tt.def, Example: long(200)}},
+				Synonyms:     related,
+				Antonyms:     related,
+			}}}
+			got := FormatWOTD(w)
+			if n := utf8.RuneCountInString(got); n > maxMessageLen {
+				t.Fatalf("message is %d characters, limit %d", n, maxMessageLen)
+			}
+			if strings.Contains(got, "Synonyms:") || strings.Contains(got, "Antonyms:") {
+				t.Error("related words kept in an oversized message")
+			}
+			if tt.wantDef && !strings.Contains(got, tt.def) {
+				t.Error("definition was cut although dropping related words was enough")
+			}
+			if !strings.HasSuffix(got, tt.wantTail) {
+				t.Errorf("message ends %q, want suffix %q", got[len(got)-10:], tt.wantTail)
+			}
+		})
+	}
+}
+
+func TestFormatWOTDShortMessageUnchanged(t *testing.T) {
+	w := WordData{Word: "terse", Meanings: []Meaning{{
+		PartOfSpeech: "adjective",
+		Definitions:  []Definition{{Definition: "brief"}},
+		Synonyms:     []string{"concise"},
+	}}}
+	if got := FormatWOTD(w); !strings.Contains(got, "Synonyms: concise") {
+		t.Errorf("short message lost its synonyms:\n%s", got)
+	}
+}
+
+func TestCapitalizeWord(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"fortitude", "Fortitude"},
+		{"éclair", "Éclair"},
+		{"o'clock", "O'clock"},
+		{"mother-in-law", "Mother-in-law"},
+		{"Fortitude", "Fortitude"},
+		{"FORTITUDE", "Fortitude"},
+		{"ärger", "Ärger"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := CapitalizeWord(tt.in); got != tt.want {
+			t.Errorf("CapitalizeWord(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPronunciationDialects(t *testing.T) {
+	uk := Phonetic{Text: "/təˈmɑːtəʊ/", Audio: "https://api.dictionaryapi.dev/media/pronunciations/en/tomato-uk.mp3"}
+	us := Phonetic{Text: "/təˈmeɪtoʊ/", Audio: "https://api.dictionaryapi.dev/media/pronunciations/en/tomato-us.mp3"}
+	tests := []struct {
+		name string
+		w    WordData
+		want string
+	}{
+		{"both dialects", WordData{Phonetic: "/təˈmɑːtəʊ/", Phonetics: []Phonetic{uk, us}}, "UK /təˈmɑːtəʊ/ · US /təˈmeɪtoʊ/"},
+		{"one dialect", WordData{Phonetic: "/x/", Phonetics: []Phonetic{uk}}, "/x/"},
+		{"no audio", WordData{Phonetics: []Phonetic{{Text: "/a/"}, {Text: "/b/"}}}, "/a/"},
+		{"same text", WordData{Phonetics: []Phonetic{uk, {Text: uk.Text, Audio: us.Audio}}}, uk.Text},
+		{"audio without text", WordData{Phonetic: "/x/", Phonetics: []Phonetic{uk, {Audio: us.Audio}}}, "/x/"},
+	}
+	for _, tt := range tests {
+		if got := tt.w.pronunciation(); got != tt.want {
+			t.Errorf("%s: pronunciation() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildWordEmbedRarityEnglishOnly(t *testing.T) {
+	w := WordData{Word: "house", Meanings: []Meaning{{Definitions: []Definition{{Definition: "a building"}}}}}
+	for _, tt := range []struct {
+		lang string
+		want bool
+	}{{"en", true}, {"es", false}} {
+		w.Lang = tt.lang
+		got := false
+		for _, f := range BuildWordEmbed(w).Fields {
+			got = got || f.Name == "Rarity"
+		}
+		if got != tt.want {
+			t.Errorf("lang %s: rarity field shown = %v, want %v", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestSpoileredHidesSynonyms(t *testing.T) {
+	w := WordData{Word: "tenacity", Meanings: []Meaning{{
+		PartOfSpeech: "noun",
+		Definitions:  []Definition{{Definition: "the quality of being tenacious", Synonyms: []string{"persistence"}}},
+		Antonyms:     []string{"irresolution"},
+	}}}
+	msg := FormatWOTD(Spoilered(w))
+	for _, want := range []string{"Synonyms: ||persistence||", "Antonyms: ||irresolution||", "||noun||"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("spoilered message %q lacks %q", msg, want)
+		}
+	}
+	if len(w.Meanings[0].Definitions[0].Synonyms[0]) != len("persistence") {
+		t.Error("Spoilered changed the original word")
+	}
+}
+
+func TestSpoileredLongDefinitionStaysHidden(t *testing.T) {
+	long := strings.Repeat("word ", 1000)
+	w := WordData{Word: "alpha", Meanings: []Meaning{{
+		Definitions: []Definition{{Definition: long, Example: long}},
+	}}}
+	msg := FormatWOTD(Spoilered(w))
+	if n := utf8.RuneCountInString(msg); n > maxMessageLen {
+		t.Errorf("message is %d runes, over the %d limit", n, maxMessageLen)
+	}
+	if n := strings.Count(msg, "||"); n == 0 || n%2 != 0 {
+		t.Errorf("message has %d spoiler markers, want balanced pairs", n)
+	}
+	if e := BuildWordEmbed(Spoilered(w)); !strings.HasSuffix(e.Description, "…||") {
+		t.Errorf("embed description ends %q, want a cut spoiler still closed", e.Description[len(e.Description)-10:])
+	}
+}
+
+func TestTruncateClosesSpoiler(t *testing.T) {
+	got := truncate("||"+strings.Repeat("a", 50)+"||", 20)
+	if utf8.RuneCountInString(got) > 20 || !strings.HasSuffix(got, "…||") {
+		t.Errorf("truncate = %q, want at most 20 runes ending in a closed spoiler", got)
+	}
+}
+
+func TestSourceCredit(t *testing.T) {
+	for _, tt := range []struct{ source, want string }{
+		{"wiktionary", "\n— via Wiktionary"},
+		{"dictionaryapi", "\n— via dictionaryapi.dev"},
+		{"", ""},
+	} {
+		if got := SourceCredit(WordData{Word: "saudade", Source: tt.source}); got != tt.want {
+			t.Errorf("SourceCredit(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package wotd
+
+import "strings"
+
+// ---------------------------
+// Base forms (light lemmatization)
+// ---------------------------
+
+// Common irregular plurals the suffix rules can't undo.
+var irregularBase = map[string]string{
+	"mice": "mouse", "geese": "goose", "feet": "foot", "teeth": "tooth",
+	"men": "man", "women": "woman", "children": "child", "people": "person",
+}
+
+// baseForms guesses dictionary forms of an inflected English word by
+// undoing plural -s/-es/-ies and -ed/-ing endings, most likely first. The
+// guesses are only looked up, so wrong ones ("boxe") just miss. Other
+// languages get none.
+func baseForms(word, lang string) []string {
+	if lang != "en" {
+		return nil
+	}
+	w := strings.ToLower(word)
+	var out []string
+	seen := map[string]bool{w: true}
+	add := func(s string) {
+		if len([]rune(s)) >= 2 && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	if base, ok := irregularBase[w]; ok {
+		add(base)
+	}
+	switch {
+	case strings.HasSuffix(w, "ies"):
+		add(strings.TrimSuffix(w, "ies") + "y") // berries
+	case strings.HasSuffix(w, "es"):
+		add(strings.TrimSuffix(w, "es")) // boxes
+		add(strings.TrimSuffix(w, "s"))  // horses
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+		add(strings.TrimSuffix(w, "s")) // cats
+	}
+	for _, suffix := range []string{"ing", "ed"} {
+		if !strings.HasSuffix(w, suffix) {
+			continue
+		}
+		stem := strings.TrimSuffix(w, suffix)
+		if suffix == "ed" && strings.HasSuffix(stem, "i") {
+			add(strings.TrimSuffix(stem, "i") + "y") // carried
+		}
+		add(stem)       // jumped
+		add(stem + "e") // baked, making
+		if n := len(stem); n >= 2 && stem[n-1] == stem[n-2] {
+			add(stem[:n-1]) // stopped, running
+		}
+	}
+	return out
+}
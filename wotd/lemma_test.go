@@ -0,0 +1,74 @@
+package wotd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestBaseForms(t *testing.T) {
+	tests := []struct{ word, want string }{
+		{"cats", "cat"},
+		{"jumped", "jump"},
+		{"boxes", "box"},
+		{"berries", "berry"},
+		{"baked", "bake"},
+		{"running", "run"},
+		{"mice", "mouse"},
+	}
+	for _, tt := range tests {
+		if got := baseForms(tt.word, "en"); !slices.Contains(got, tt.want) {
+			t.Errorf("baseForms(%q) = %q, want it to include %q", tt.word, got, tt.want)
+		}
+	}
+	if got := baseForms("glass", "en"); slices.Contains(got, "glas") {
+		t.Errorf("baseForms(glass) = %q, should not strip -ss", got)
+	}
+	if got := baseForms("gatos", "es"); got != nil {
+		t.Errorf("baseForms for es = %q, want none", got)
+	}
+}
+
+// lexiconProvider only defines the words it knows.
+type lexiconProvider map[string]string
+
+func (lexiconProvider) Name() string { return "lexicon" }
+
+func (p lexiconProvider) Define(_ context.Context, word, _ string) (WordData, error) {
+	def, ok := p[word]
+	if !ok {
+		return WordData{}, fmt.Errorf("%w for %s", ErrNoDefinition, word)
+	}
+	return WordData{Word: word, Meanings: []Meaning{{Definitions: []Definition{{Definition: def}}}}}, nil
+}
+
+func TestFetchDefinitionFallsBackToBaseForm(t *testing.T) {
+	prevProviders, prevCache := Providers, Definitions
+	Providers = []DefinitionProvider{lexiconProvider{
+		"cat":  "A small domesticated feline.",
+		"jump": "To propel oneself into the air.",
+		"box":  "A container with flat sides.",
+	}}
+	Definitions = nil
+	t.Cleanup(func() { Providers, Definitions = prevProviders, prevCache })
+
+	tests := []struct{ word, wantDef string }{
+		{"cats", "A small domesticated feline."},
+		{"jumped", "To propel oneself into the air."},
+		{"boxes", "A container with flat sides."},
+	}
+	for _, tt := range tests {
+		data, err := FetchDefinition(context.Background(), tt.word, "en")
+		if err != nil {
+			t.Errorf("FetchDefinition(%q): %v", tt.word, err)
+			continue
+		}
+		if data.Word != tt.word {
+			t.Errorf("FetchDefinition(%q).Word = %q, want the original word", tt.word, data.Word)
+		}
+		if got := data.Meanings[0].Definitions[0].Definition; got != tt.wantDef {
+			t.Errorf("FetchDefinition(%q) definition = %q, want %q", tt.word, got, tt.wantDef)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package wotd
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ---------------------------
+// Prometheus metrics (registered on the default registry, so any /metrics
+// handler serves them)
+// ---------------------------
+
+var (
+	apiFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wotd_api_failures_total",
+		Help: "Failed requests to the word and dictionary APIs (not counting unknown words).",
+	}, []string{"provider"})
+	definitionLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "wotd_definition_fetch_seconds",
+		Help:    "Time taken by a definition provider lookup.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider"})
+	definitionMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "wotd_definition_misses_total",
+		Help: "Candidate words GetWOTD found no definition for (unknown word, dictionary error or open breaker).",
+	})
+	wotdFallbacks = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "wotd_fallbacks_total",
+		Help: "GetWOTD calls that ran out of attempts and fell back, to a word without a definition or none.",
+	})
+)
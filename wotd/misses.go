@@ -0,0 +1,76 @@
+package wotd
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// ---------------------------
+// Definition miss rate
+// ---------------------------
+
+// MissRate tracks how many of the last window candidate words GetWOTD
+// looked up came back without a definition, whether the dictionaries didn't
+// know the word or failed outright, and warns once the share goes over a
+// percentage: a run of misses usually means a dictionary is degraded or down
+// rather than that the words were unlucky. A nil MissRate tracks nothing.
+type MissRate struct {
+	mu      sync.Mutex
+	percent float64
+	window  []bool // ring of recent lookups; true = miss
+	next    int
+	filled  bool
+	misses  int
+	warned  bool // over the threshold, already warned
+}
+
+// NewMissRate warns when more than percent of the last window lookups
+// missed. A percent or window of 0 or less disables it (nil).
+func NewMissRate(window int, percent float64) *MissRate {
+	if window <= 0 || percent <= 0 {
+		return nil
+	}
+	return &MissRate{percent: percent, window: make([]bool, window)}
+}
+
+// Record adds one lookup. Until the window has filled up there is too
+// little to go on, so nothing is logged.
+func (r *MissRate) Record(miss bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.filled && r.window[r.next] {
+		r.misses--
+	}
+	r.window[r.next] = miss
+	if miss {
+		r.misses++
+	}
+	r.next = (r.next + 1) % len(r.window)
+	if r.next == 0 {
+		r.filled = true
+	}
+	if !r.filled {
+		return
+	}
+	rate := r.rate()
+	switch {
+	case rate > r.percent && !r.warned:
+		r.warned = true
+		slog.Warn("[wotd] many words without a definition, a dictionary may be degraded",
+			"miss_percent", rate, "threshold", r.percent, "lookups", len(r.window))
+	case rate <= r.percent && r.warned:
+		r.warned = false
+		slog.Info("[wotd] definition miss rate back to normal", "miss_percent", rate, "threshold", r.percent)
+	}
+}
+
+// rate is the share of misses in the window, in percent.
+func (r *MissRate) rate() float64 {
+	return float64(r.misses) * 100 / float64(len(r.window))
+}
+
+// Misses watches GetWOTD's lookups; set from config in main.
+var Misses *MissRate
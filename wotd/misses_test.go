@@ -0,0 +1,46 @@
+package wotd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMissRateWarnsOverThreshold(t *testing.T) {
+	r := NewMissRate(4, 50)
+	for _, miss := range []bool{true, true, true} {
+		r.Record(miss)
+	}
+	if r.warned {
+		t.Fatal("warned before the window filled up")
+	}
+	r.Record(false) // 3 of 4 missed
+	if !r.warned {
+		t.Fatalf("not warned at %.0f%% misses, threshold 50%%", r.rate())
+	}
+	r.Record(false) // the oldest miss drops out: 2 of 4
+	if r.warned || r.rate() != 50 {
+		t.Errorf("rate = %.0f%%, warned = %v; want 50%% and recovered", r.rate(), r.warned)
+	}
+}
+
+func TestNewMissRateDisabled(t *testing.T) {
+	r := NewMissRate(50, 0)
+	if r != nil {
+		t.Fatal("NewMissRate with 0% should disable tracking")
+	}
+	r.Record(true) // nil-safe
+}
+
+func TestGetWOTDCountsFailedLookupsAsMisses(t *testing.T) {
+	prevSource, prevProviders, prevCache, prevBreakers, prevMisses := Source, Providers, Definitions, Breakers, Misses
+	Source, Providers, Definitions, Breakers = &seqSource{"alpha", "bravo"}, []DefinitionProvider{&downProvider{}}, nil, nil
+	Misses = NewMissRate(2, 50)
+	t.Cleanup(func() {
+		Source, Providers, Definitions, Breakers, Misses = prevSource, prevProviders, prevCache, prevBreakers, prevMisses
+	})
+
+	GetWOTD(context.Background(), 2, NewSelector(DefaultPrefs, nil, nil), DefaultPrefs.Lang)
+	if !Misses.filled || Misses.misses != 2 {
+		t.Errorf("after two failed lookups: filled = %v, misses = %d; want both recorded as misses", Misses.filled, Misses.misses)
+	}
+}
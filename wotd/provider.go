@@ -0,0 +1,345 @@
+package wotd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ---------------------------
+// Definition providers
+// ---------------------------
+
+// ErrNoDefinition is returned when the dictionary has no entry for a word.
+var ErrNoDefinition = errors.New("no definition")
+
+// DefinitionProvider looks up a word. On success the result has at least
+// one meaning with at least one definition; a missing word is reported as
+// ErrNoDefinition.
+type DefinitionProvider interface {
+	Name() string
+	Define(ctx context.Context, word, lang string) (WordData, error)
+}
+
+// Providers tried in order by FetchDefinition; set from config in main.
+var Providers = []DefinitionProvider{dictionaryAPI{}}
+
+var providersByName = map[string]DefinitionProvider{
+	"dictionaryapi": dictionaryAPI{},
+	"wiktionary":    wiktionary{},
+}
+
+// Names shown to users for where a definition came from.
+var providerLabels = map[string]string{
+	"dictionaryapi": "dictionaryapi.dev",
+	"wiktionary":    "Wiktionary",
+}
+
+// sourceLabel is the user-facing name of the provider that defined w, or ""
+// if unknown.
+func (w WordData) sourceLabel() string {
+	if label, ok := providerLabels[w.Source]; ok {
+		return label
+	}
+	return w.Source
+}
+
+// ProvidersFromNames maps DEFINITION_PROVIDERS entries to providers,
+// skipping unknown names.
+func ProvidersFromNames(names []string) []DefinitionProvider {
+	var out []DefinitionProvider
+	for _, name := range names {
+		p, ok := providersByName[strings.ToLower(name)]
+		if !ok {
+			slog.Warn("[config] unknown definition provider", "name", name)
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Successful lookups, keyed by language and lowercase word; replaced from config in main.
+var Definitions = NewDefCache(500, 0)
+
+// FetchDefinition serves from the cache, otherwise tries each provider in
+// order until one succeeds. If none has the word, its likely base forms
+// ("jumped" → jump) are tried the same way; a hit keeps the original word
+// for display. Failures are not cached so newly added words can resolve
+// later.
+func FetchDefinition(ctx context.Context, word, lang string) (WordData, error) {
+	key := lang + ":" + strings.ToLower(word)
+	if data, ok := Definitions.Get(key); ok {
+		return data, nil
+	}
+	data, err := lookupDefinition(ctx, word, lang)
+	if errors.Is(err, ErrNoDefinition) {
+		for _, base := range baseForms(word, lang) {
+			d, baseErr := lookupDefinition(ctx, base, lang)
+			if baseErr == nil {
+				LogFrom(ctx).Debug("[define] defined via base form", "word", word, "base", base)
+				d.Word = word
+				data, err = d, nil
+				break
+			}
+			if !errors.Is(baseErr, ErrNoDefinition) {
+				break // the API is struggling; don't pile on more guesses
+			}
+		}
+	}
+	if err != nil {
+		return WordData{}, err
+	}
+	data.Lang = lang
+	Definitions.Put(key, data)
+	return data, nil
+}
+
+// lookupDefinition tries each provider in order until one succeeds and
+// returns the last error if none do. A lookup that fails because ctx ran out
+// isn't held against the provider, in its breaker or in apiFailures.
+func lookupDefinition(ctx context.Context, word, lang string) (WordData, error) {
+	lastErr := fmt.Errorf("%w for %s", ErrNoDefinition, word)
+	for _, p := range Providers {
+		br := Breakers.get(p.Name())
+		if !br.Allow() {
+			LogFrom(ctx).Debug("[define] breaker open, skipping", "word", word, "provider", p.Name())
+			lastErr = fmt.Errorf("%s: %w", p.Name(), errBreakerOpen)
+			continue
+		}
+		start := time.Now()
+		data, err := p.Define(ctx, word, lang)
+		definitionLatency.WithLabelValues(p.Name()).Observe(time.Since(start).Seconds())
+		if err != nil && ctx.Err() != nil {
+			br.Abandon()
+			return WordData{}, err // out of time; the next provider would fail the same way
+		}
+		br.Record(err)
+		if err == nil {
+			LogFrom(ctx).Debug("[define] defined", "word", word, "provider", p.Name())
+			data.Source = p.Name()
+			return data, nil
+		}
+		if !errors.Is(err, ErrNoDefinition) {
+			apiFailures.WithLabelValues(p.Name()).Inc()
+		}
+		lastErr = err
+	}
+	return WordData{}, lastErr
+}
+
+// dictionaryapi.dev (Free Dictionary API)
+type dictionaryAPI struct{}
+
+func (dictionaryAPI) Name() string { return "dictionaryapi" }
+
+func (dictionaryAPI) Define(ctx context.Context, word, lang string) (WordData, error) {
+	endpoint := fmt.Sprintf("https://api.dictionaryapi.dev/api/v2/entries/%s/%s", url.PathEscape(lang), url.PathEscape(word))
+	resp, err := getWithRetry(ctx, endpoint, HTTPAttempts)
+	if err != nil {
+		return WordData{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return WordData{}, fmt.Errorf("%w for %s", ErrNoDefinition, word)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return WordData{}, fmt.Errorf("dictionaryapi status %d", resp.StatusCode)
+	}
+	var raw json.RawMessage
+	if err := decodeJSON(resp, "dictionary API", &raw); err != nil {
+		return WordData{}, err
+	}
+	if len(raw) > 0 && raw[0] == '{' {
+		return WordData{}, dictionaryAPIError(raw, word)
+	}
+	var data []WordData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return WordData{}, err
+	}
+	if len(data) == 0 || len(data[0].Meanings) == 0 || len(data[0].Meanings[0].Definitions) == 0 {
+		return WordData{}, fmt.Errorf("%w for %s", ErrNoDefinition, word)
+	}
+	return data[0], nil
+}
+
+// dictionaryAPIError turns the object the dictionary API sometimes sends in
+// place of the entry array, e.g. {"title": "No Definitions Found",
+// "message": "Sorry pal, …"}, into an error carrying its message.
+func dictionaryAPIError(raw json.RawMessage, word string) error {
+	var body struct {
+		Title   string `json:"title"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return err
+	}
+	msg := body.Message
+	if msg == "" {
+		msg = body.Title
+	}
+	if body.Title == "No Definitions Found" {
+		return fmt.Errorf("%w for %s: %s", ErrNoDefinition, word, msg)
+	}
+	return fmt.Errorf("dictionary API: %s", msg)
+}
+
+// Wiktionary REST API. Definitions come back as HTML snippets keyed by
+// language code.
+type wiktionary struct{}
+
+func (wiktionary) Name() string { return "wiktionary" }
+
+type wiktionaryUsage struct {
+	PartOfSpeech string `json:"partOfSpeech"`
+	Definitions  []struct {
+		Definition string   `json:"definition"`
+		Examples   []string `json:"examples"`
+	} `json:"definitions"`
+}
+
+func (wiktionary) Define(ctx context.Context, word, lang string) (WordData, error) {
+	endpoint := fmt.Sprintf("https://en.wiktionary.org/api/rest_v1/page/definition/%s", url.PathEscape(word))
+	resp, err := getWithRetry(ctx, endpoint, HTTPAttempts)
+	if err != nil {
+		return WordData{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return WordData{}, fmt.Errorf("%w for %s", ErrNoDefinition, word)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return WordData{}, fmt.Errorf("wiktionary status %d", resp.StatusCode)
+	}
+	var byLang map[string][]wiktionaryUsage
+	if err := decodeJSON(resp, "Wiktionary", &byLang); err != nil {
+		return WordData{}, err
+	}
+	data := WordData{Word: word}
+	for _, u := range byLang[wiktionaryLangKey(lang)] {
+		m := Meaning{PartOfSpeech: strings.ToLower(u.PartOfSpeech)}
+		for _, d := range u.Definitions {
+			def := stripHTML(d.Definition)
+			if def == "" {
+				continue
+			}
+			var ex string
+			if len(d.Examples) > 0 {
+				ex = stripHTML(d.Examples[0])
+			}
+			m.Definitions = append(m.Definitions, Definition{Definition: def, Example: ex})
+		}
+		if len(m.Definitions) > 0 {
+			data.Meanings = append(data.Meanings, m)
+		}
+	}
+	if len(data.Meanings) == 0 {
+		return WordData{}, fmt.Errorf("%w for %s", ErrNoDefinition, word)
+	}
+	// Etymology is a nice-to-have; the definition stands without it.
+	ety, err := wiktionaryEtymology(ctx, word, lang)
+	if err != nil {
+		LogFrom(ctx).Debug("[wiktionary] no etymology", "word", word, "err", err)
+	}
+	data.Etymology = ety
+	return data, nil
+}
+
+// wiktionaryLangKey is the REST response key for lang: Wiktionary files
+// Brazilian Portuguese under plain Portuguese.
+func wiktionaryLangKey(lang string) string {
+	if lang == "pt-br" {
+		return "pt"
+	}
+	return lang
+}
+
+// Wiktionary section headings per language code.
+var wiktionaryLangNames = map[string]string{
+	"en": "English", "es": "Spanish", "it": "Italian", "de": "German",
+	"fr": "French", "zh": "Chinese", "pt": "Portuguese", "pt-br": "Portuguese",
+}
+
+// Longest etymology shown, in characters.
+const maxEtymology = 300
+
+var sectionHeading = regexp.MustCompile(`(?m)^(={2,})\s*(.*?)\s*={2,}\s*$`)
+
+// wiktionaryEtymology fetches the page as plain text and returns the first
+// "Etymology" paragraph under the language's section, truncated.
+func wiktionaryEtymology(ctx context.Context, word, lang string) (string, error) {
+	q := url.Values{
+		"action":      {"query"},
+		"prop":        {"extracts"},
+		"explaintext": {"1"},
+		"redirects":   {"1"},
+		"format":      {"json"},
+		"titles":      {word},
+	}
+	resp, err := getWithRetry(ctx, "https://en.wiktionary.org/w/api.php?"+q.Encode(), HTTPAttempts)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("wiktionary status %d", resp.StatusCode)
+	}
+	var body struct {
+		Query struct {
+			Pages map[string]struct {
+				Extract string `json:"extract"`
+			} `json:"pages"`
+		} `json:"query"`
+	}
+	if err := decodeJSON(resp, "Wiktionary", &body); err != nil {
+		return "", err
+	}
+	for _, page := range body.Query.Pages {
+		if ety := etymologyFromExtract(page.Extract, wiktionaryLangNames[lang]); ety != "" {
+			return truncate(ety, maxEtymology), nil
+		}
+	}
+	return "", errors.New("no etymology section")
+}
+
+// etymologyFromExtract finds the first Etymology section inside the
+// language's level-2 section of a plain-text page extract.
+func etymologyFromExtract(extract, langName string) string {
+	if langName == "" {
+		return ""
+	}
+	heads := sectionHeading.FindAllStringSubmatchIndex(extract, -1)
+	inLang := false
+	for n, h := range heads {
+		level, title := extract[h[2]:h[3]], extract[h[4]:h[5]]
+		if len(level) == 2 {
+			inLang = title == langName
+			continue
+		}
+		if !inLang || !strings.HasPrefix(title, "Etymology") {
+			continue
+		}
+		end := len(extract)
+		if n+1 < len(heads) {
+			end = heads[n+1][0]
+		}
+		text := strings.TrimSpace(extract[h[1]:end])
+		para, _, _ := strings.Cut(text, "\n")
+		return strings.TrimSpace(para)
+	}
+	return ""
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+func stripHTML(s string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(s, "")))
+}
@@ -0,0 +1,128 @@
+package wotd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const fortitudeJSON = `[{
+	"word": "fortitude",
+	"meanings": [{
+		"partOfSpeech": "noun",
+		"definitions": [{"definition": "Mental and emotional strength in facing difficulty.", "example": "she showed great fortitude"}]
+	}]
+}]`
+
+func TestDictionaryAPIDefine(t *testing.T) {
+	tests := []struct {
+		name    string
+		doer    stubDoer
+		wantDef string
+		wantErr error // nil: any error is accepted when wantDef is empty
+	}{
+		{"ok", stubDoer{http.StatusOK, fortitudeJSON}, "Mental and emotional strength in facing difficulty.", nil},
+		{"empty array", stubDoer{http.StatusOK, `[]`}, "", ErrNoDefinition},
+		{"not found", stubDoer{http.StatusNotFound, `{"title":"No Definitions Found"}`}, "", ErrNoDefinition},
+		{"malformed json", stubDoer{http.StatusOK, `[{"word":`}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDoer(t, tt.doer)
+			data, err := dictionaryAPI{}.Define(context.Background(), "fortitude", "en")
+			if tt.wantDef == "" {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := data.Meanings[0].Definitions[0].Definition; got != tt.wantDef {
+				t.Errorf("definition = %q, want %q", got, tt.wantDef)
+			}
+		})
+	}
+}
+
+const saudadeJSON = `{
+	"pt": [{
+		"partOfSpeech": "Noun",
+		"definitions": [{"definition": "<b>longing</b> for something absent", "examples": []}]
+	}]
+}`
+
+func TestWiktionaryDefine(t *testing.T) {
+	tests := []struct {
+		lang    string
+		wantDef string // "": ErrNoDefinition
+	}{
+		{"pt", "longing for something absent"},
+		{"pt-br", "longing for something absent"},
+		{"en", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			withDoer(t, stubDoer{http.StatusOK, saudadeJSON})
+			data, err := wiktionary{}.Define(context.Background(), "saudade", tt.lang)
+			if tt.wantDef == "" {
+				if !errors.Is(err, ErrNoDefinition) {
+					t.Fatalf("err = %v, want %v", err, ErrNoDefinition)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := data.Meanings[0].Definitions[0].Definition; got != tt.wantDef {
+				t.Errorf("definition = %q, want %q", got, tt.wantDef)
+			}
+		})
+	}
+}
+
+// typedDoer is a stubDoer that also sets a Content-Type.
+type typedDoer struct {
+	stubDoer
+	contentType string
+}
+
+func (d typedDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.stubDoer.Do(req)
+	resp.Header.Set("Content-Type", d.contentType)
+	return resp, err
+}
+
+func TestDictionaryAPIDefineHTMLPage(t *testing.T) {
+	withDoer(t, typedDoer{stubDoer{http.StatusOK, `<!DOCTYPE html><html><title>Just a moment...</title></html>`}, "text/html; charset=UTF-8"})
+	_, err := dictionaryAPI{}.Define(context.Background(), "fortitude", "en")
+	if err == nil || !strings.Contains(err.Error(), "unexpected content type text/html") {
+		t.Fatalf("err = %v, want an unexpected content type error", err)
+	}
+
+	withDoer(t, typedDoer{stubDoer{http.StatusOK, fortitudeJSON}, "application/json; charset=utf-8"})
+	_, err = dictionaryAPI{}.Define(context.Background(), "fortitude", "en")
+	if err != nil {
+		t.Fatalf("JSON with charset: unexpected error: %v", err)
+	}
+}
+
+func TestDictionaryAPIDefineErrorObject(t *testing.T) {
+	withDoer(t, stubDoer{http.StatusOK, `{"title":"Something Went Wrong","message":"Sorry pal, something went wrong.","resolution":"Try again later."}`})
+	_, err := dictionaryAPI{}.Define(context.Background(), "fortitude", "en")
+	if err == nil || !strings.Contains(err.Error(), "Sorry pal, something went wrong.") {
+		t.Fatalf("err = %v, want the API's message", err)
+	}
+
+	withDoer(t, stubDoer{http.StatusOK, `{"title":"No Definitions Found","message":"Sorry pal, we couldn't find definitions for the word you were looking for."}`})
+	_, err = dictionaryAPI{}.Define(context.Background(), "fortitude", "en")
+	if !errors.Is(err, ErrNoDefinition) {
+		t.Fatalf("err = %v, want ErrNoDefinition", err)
+	}
+}
@@ -0,0 +1,137 @@
+package wotd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ---------------------------
+// Random word APIs
+// ---------------------------
+
+// RandomWordSource is one random word API. Random returns a word within p's
+// length bounds and language, or an error if the API can't provide one.
+// fetchRandomWord only asks APIs that support the language.
+type RandomWordSource interface {
+	Name() string
+	Supports(lang string) bool
+	Random(ctx context.Context, p Prefs) (string, error)
+}
+
+// APIs tried in order by fetchRandomWord; set from config in main.
+var RandomWordAPIs = []RandomWordSource{herokuWordAPI{}}
+
+var randomWordAPIsByName = map[string]RandomWordSource{
+	"heroku": herokuWordAPI{},
+	"vercel": vercelWordAPI{},
+}
+
+// RandomWordAPIsFromNames maps RANDOM_WORD_APIS entries to APIs, skipping
+// unknown names.
+func RandomWordAPIsFromNames(names []string) []RandomWordSource {
+	var out []RandomWordSource
+	for _, name := range names {
+		api, ok := randomWordAPIsByName[strings.ToLower(name)]
+		if !ok {
+			slog.Warn("[config] unknown random word API", "name", name)
+			continue
+		}
+		out = append(out, api)
+	}
+	return out
+}
+
+// Batch size requested when filtering word length client-side.
+const lengthFilterBatch = 25
+
+// lengthQuery picks how many words to ask for, and an exact length when
+// both bounds are set (the APIs only filter by exact length). An open-ended
+// bound is filtered client-side from a batch instead.
+func lengthQuery(p Prefs) (count, length int) {
+	switch {
+	case p.MinLen > 0 && p.MaxLen >= p.MinLen:
+		return 1, p.MinLen + rand.Intn(p.MaxLen-p.MinLen+1)
+	case p.MinLen > 0 || p.MaxLen > 0:
+		return lengthFilterBatch, 0
+	}
+	return 1, 0
+}
+
+// firstInRange returns the first word within p's length bounds.
+func firstInRange(words []string, p Prefs) (string, error) {
+	for _, w := range words {
+		n := utf8.RuneCountInString(w)
+		if p.MinLen > 0 && n < p.MinLen || p.MaxLen > 0 && n > p.MaxLen {
+			continue
+		}
+		return w, nil
+	}
+	return "", fmt.Errorf("no word returned")
+}
+
+// getWordList fetches a JSON array of words.
+func getWordList(ctx context.Context, endpoint, api string) ([]string, error) {
+	resp, err := getWithRetry(ctx, endpoint, HTTPAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s status %d", api, resp.StatusCode)
+	}
+	var words RandomWordResponse
+	if err := decodeJSON(resp, api, &words); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// random-word-api.herokuapp.com
+type herokuWordAPI struct{}
+
+func (herokuWordAPI) Name() string { return "heroku" }
+
+func (herokuWordAPI) Supports(string) bool { return true }
+
+func (herokuWordAPI) Random(ctx context.Context, p Prefs) (string, error) {
+	count, length := lengthQuery(p)
+	q := url.Values{"number": {strconv.Itoa(count)}}
+	if length > 0 {
+		q.Set("length", strconv.Itoa(length))
+	}
+	if p.Lang != "en" {
+		q.Set("lang", p.Lang)
+	}
+	words, err := getWordList(ctx, "https://random-word-api.herokuapp.com/word?"+q.Encode(), "random word API")
+	if err != nil {
+		return "", err
+	}
+	return firstInRange(words, p)
+}
+
+// random-word-api.vercel.app, English only.
+type vercelWordAPI struct{}
+
+func (vercelWordAPI) Name() string { return "vercel" }
+
+func (vercelWordAPI) Supports(lang string) bool { return lang == "en" }
+
+func (vercelWordAPI) Random(ctx context.Context, p Prefs) (string, error) {
+	count, length := lengthQuery(p)
+	q := url.Values{"words": {strconv.Itoa(count)}}
+	if length > 0 {
+		q.Set("length", strconv.Itoa(length))
+	}
+	words, err := getWordList(ctx, "https://random-word-api.vercel.app/api?"+q.Encode(), "vercel random word API")
+	if err != nil {
+		return "", err
+	}
+	return firstInRange(words, p)
+}
@@ -0,0 +1,111 @@
+package wotd
+
+import (
+	"math/rand"
+	"time"
+)
+
+// ---------------------------
+// Schedule math
+// ---------------------------
+
+// A daily post time, local to the scheduler's TZ.
+type PostTime struct{ Hour, Minute int }
+
+// ParseHM parses a 24h HH:MM time.
+func ParseHM(hm string) (PostTime, bool) {
+	t, err := time.Parse("15:04", hm)
+	if err != nil {
+		return PostTime{}, false
+	}
+	return PostTime{Hour: t.Hour(), Minute: t.Minute()}, true
+}
+
+// Schedule describes when scheduled posts fire.
+type Schedule struct {
+	Times        []PostTime
+	SkipWeekends bool
+}
+
+// At returns the post time pt on the day offset days from now's date.
+//
+// On a spring-forward day pt may not exist (02:30 in America/New_York), and
+// time.Date then lands before the gap; shift it forward by the gap so the
+// post happens just after the clocks jump instead of an hour early. An
+// ambiguous fall-back time resolves to its first occurrence only.
+func At(now time.Time, days int, pt PostTime) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day()+days, pt.Hour, pt.Minute, 0, 0, now.Location())
+	want := time.Date(now.Year(), now.Month(), now.Day()+days, pt.Hour, pt.Minute, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	return t.Add(want.Sub(got))
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// Next returns the soonest post time strictly after now, in now's location.
+func (sc Schedule) Next(now time.Time) time.Time {
+	for days := 0; days <= 7; days++ {
+		var next time.Time
+		for _, pt := range sc.Times {
+			t := At(now, days, pt)
+			if !t.After(now) || sc.SkipWeekends && isWeekend(t) {
+				continue
+			}
+			if next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// Prev returns the latest post time at or before now, in now's location.
+func (sc Schedule) Prev(now time.Time) time.Time {
+	for days := 0; days >= -7; days-- {
+		var prev time.Time
+		for _, pt := range sc.Times {
+			t := At(now, days, pt)
+			if t.After(now) || sc.SkipWeekends && isWeekend(t) {
+				continue
+			}
+			if t.After(prev) {
+				prev = t
+			}
+		}
+		if !prev.IsZero() {
+			return prev
+		}
+	}
+	return time.Time{}
+}
+
+func SameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// Jitter delays slot by a random 0..jitterWindow.
+func Jitter(slot, following time.Time, max time.Duration) time.Time {
+	w := JitterWindow(slot, following, max)
+	if w <= 0 {
+		return slot
+	}
+	return slot.Add(time.Duration(rand.Int63n(int64(w) + 1)))
+}
+
+// JitterWindow is the most a post at slot may be delayed: max, kept short
+// of the following slot (if any) so a post never slides into the next one.
+func JitterWindow(slot, following time.Time, max time.Duration) time.Duration {
+	if !following.IsZero() {
+		if gap := following.Sub(slot) - time.Second; gap < max {
+			max = gap
+		}
+	}
+	return max
+}
@@ -0,0 +1,97 @@
+package wotd
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoc(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("tzdata for %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+func TestNextRunDST(t *testing.T) {
+	ny := mustLoc(t, "America/New_York")
+	cases := []struct {
+		name string
+		now  time.Time
+		pt   PostTime
+		want time.Time
+	}{
+		{
+			name: "spring forward, skipped time moves past the gap",
+			now:  time.Date(2024, 3, 10, 0, 0, 0, 0, ny),
+			pt:   PostTime{2, 30},
+			want: time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC), // 03:30 EDT
+		},
+		{
+			name: "spring forward, now inside the old gap hour",
+			now:  time.Date(2024, 3, 10, 1, 45, 0, 0, ny),
+			pt:   PostTime{2, 30},
+			want: time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "fall back, ambiguous time uses first occurrence",
+			now:  time.Date(2024, 11, 3, 0, 0, 0, 0, ny),
+			pt:   PostTime{1, 30},
+			want: time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+		},
+		{
+			name: "fall back, second occurrence does not fire again",
+			now:  time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC).In(ny),
+			pt:   PostTime{1, 30},
+			want: time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC), // next day, 01:30 EST
+		},
+		{
+			name: "ordinary day after spring forward",
+			now:  time.Date(2024, 3, 10, 12, 0, 0, 0, ny),
+			pt:   PostTime{2, 30},
+			want: time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC), // 02:30 EDT
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Schedule{Times: []PostTime{tc.pt}}.Next(tc.now)
+			if !got.Equal(tc.want) {
+				t.Errorf("Next(%v) = %v, want %v", tc.now, got, tc.want.In(ny))
+			}
+		})
+	}
+}
+
+// Following Next from post to post across both transitions of a year
+// must give exactly one post per local day.
+func TestNextRunOncePerDayAcrossDST(t *testing.T) {
+	ny := mustLoc(t, "America/New_York")
+	for _, pt := range []PostTime{{0, 30}, {1, 30}, {2, 0}, {2, 30}, {3, 0}, {9, 0}} {
+		sc := Schedule{Times: []PostTime{pt}}
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, ny)
+		end := time.Date(2024, 11, 30, 0, 0, 0, 0, ny)
+		posts := map[string]int{}
+		days := 0
+		for now := start; ; {
+			next := sc.Next(now)
+			if !next.After(now) {
+				t.Fatalf("%v: Next(%v) = %v, not after now", pt, now, next)
+			}
+			if !next.Before(end) {
+				break
+			}
+			posts[next.Format("2006-01-02")]++
+			now = next
+		}
+		for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+			days++
+			if n := posts[d.Format("2006-01-02")]; n != 1 {
+				t.Errorf("%v: %d posts on %s, want 1", pt, n, d.Format("2006-01-02"))
+			}
+		}
+		if len(posts) != days {
+			t.Errorf("%v: posts on %d days, want %d", pt, len(posts), days)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package wotd
+
+import (
+	"context"
+	"errors"
+)
+
+// ---------------------------
+// Word selection
+// ---------------------------
+
+// Selector proposes one candidate word per call for GetWOTD. Filters wrap
+// another Selector and reject what they don't want, so strategies compose.
+type Selector interface {
+	Select(ctx context.Context) (string, error)
+}
+
+var (
+	// errRejected: the word must never be posted (blocked, not a plain word).
+	errRejected = errors.New("word rejected")
+	// errUnfit: the word is returned anyway and may serve as a last resort
+	// (wrong difficulty).
+	errUnfit = errors.New("word does not fit")
+	// errRepeated: the word was posted recently. Avoiding repeats is the
+	// point of history, so unlike an unfit word it is never a last resort.
+	errRepeated = errors.New("word posted recently")
+)
+
+// RandomSelector takes words from a WordSource as they come.
+type RandomSelector struct{ src WordSource }
+
+func (rs RandomSelector) Select(ctx context.Context) (string, error) {
+	return rs.src.Next(ctx)
+}
+
+// blocklistFilter rejects BLOCKLIST_PATH words and tokens plainWord refuses.
+type blocklistFilter struct{ next Selector }
+
+func (f blocklistFilter) Select(ctx context.Context) (string, error) {
+	word, err := f.next.Select(ctx)
+	if err == nil && !Allowed(word) {
+		return "", errRejected
+	}
+	return word, err
+}
+
+// Allowed reports whether word may be posted at all: it isn't on the
+// blocklist and plainWord accepts it. Words that don't come through a
+// Selector, such as a /setword pin, are checked with it directly.
+func Allowed(word string) bool {
+	return !Blocked.Has(word) && plainWord(word)
+}
+
+// rejectFilter rejects words the caller rules out, such as ones reported
+// through /feedback or already picked for the same post.
+type rejectFilter struct {
+	next   Selector
+	reject func(word string) bool
+}
+
+func (f rejectFilter) Select(ctx context.Context) (string, error) {
+	word, err := f.next.Select(ctx)
+	if err == nil && f.reject(word) {
+		return "", errRejected
+	}
+	return word, err
+}
+
+// difficultyFilter marks words outside the DIFFICULTY tier as unfit.
+type difficultyFilter struct {
+	next Selector
+	d    Difficulty
+	lang string
+}
+
+func (f difficultyFilter) Select(ctx context.Context) (string, error) {
+	word, err := f.next.Select(ctx)
+	if err == nil && !f.d.fits(word, f.lang) {
+		return word, errUnfit
+	}
+	return word, err
+}
+
+// History is the record of posted words the history filter consults.
+type History interface {
+	Contains(word string) bool
+}
+
+// historyFilter rejects recently posted words, including ones an inner
+// filter only marked unfit.
+type historyFilter struct {
+	next Selector
+	hist History
+}
+
+func (f historyFilter) Select(ctx context.Context) (string, error) {
+	word, err := f.next.Select(ctx)
+	if (err == nil || errors.Is(err, errUnfit)) && f.hist.Contains(word) {
+		return "", errRepeated
+	}
+	return word, err
+}
+
+// NewSelector is the standard strategy: words from p's source, minus the
+// blocklist, whatever reject rules out and the words in hist, preferring
+// ones that fit p's difficulty. hist and reject may be nil.
+//
+// A deterministic list skips the history check: it doesn't repeat until it
+// wraps around anyway, and the first server's post mustn't push the others
+// onto a different word.
+func NewSelector(p Prefs, hist History, reject func(string) bool) Selector {
+	var sel Selector = RandomSelector{src: p.source()}
+	sel = blocklistFilter{next: sel}
+	if reject != nil {
+		sel = rejectFilter{next: sel, reject: reject}
+	}
+	sel = difficultyFilter{next: sel, d: p.Difficulty, lang: p.Lang}
+	if hist != nil && !deterministic() {
+		sel = historyFilter{next: sel, hist: hist}
+	}
+	return sel
+}
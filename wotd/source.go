@@ -0,0 +1,136 @@
+package wotd
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ---------------------------
+// Word sources
+// ---------------------------
+
+// WordSource supplies raw candidate words for RandomSelector.
+type WordSource interface {
+	Next(ctx context.Context) (string, error)
+}
+
+// randomSource draws words from the random word API.
+type randomSource struct{ prefs Prefs }
+
+func (rs randomSource) Next(ctx context.Context) (string, error) {
+	return fetchRandomWord(ctx, rs.prefs)
+}
+
+// FileSource cycles through a curated word list, reshuffling on every pass
+// when shuffle is set.
+type FileSource struct {
+	mu      sync.Mutex
+	words   []string
+	next    int
+	shuffle bool
+}
+
+// LoadFileSource reads a word list in the blocklist format; without shuffle
+// words come out in file order.
+func LoadFileSource(path string, shuffle bool) (*FileSource, error) {
+	words, err := LoadWordList(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, errors.New("word list is empty")
+	}
+	return &FileSource{words: words, shuffle: shuffle}, nil
+}
+
+// Len is the number of words in the list.
+func (fs *FileSource) Len() int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return len(fs.words)
+}
+
+func (fs *FileSource) Next(context.Context) (string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.next == 0 && fs.shuffle {
+		rand.Shuffle(len(fs.words), func(i, j int) { fs.words[i], fs.words[j] = fs.words[j], fs.words[i] })
+	}
+	w := fs.words[fs.next]
+	fs.next = (fs.next + 1) % len(fs.words)
+	return w, nil
+}
+
+// DeterministicSource is a word list walked one word per calendar day in
+// loc (DETERMINISTIC=1), so every server gets the same word on a date and
+// a restart doesn't change it. Shuffling uses a fixed seed for the same
+// reason.
+type DeterministicSource struct {
+	words []string
+	loc   *time.Location
+}
+
+// NewDeterministicSource walks fs's words by date in loc, with the fixed
+// shuffle if fs shuffles.
+func NewDeterministicSource(fs *FileSource, loc *time.Location) *DeterministicSource {
+	fs.mu.Lock()
+	words := slices.Clone(fs.words)
+	fs.mu.Unlock()
+	if fs.shuffle {
+		r := rand.New(rand.NewSource(1))
+		r.Shuffle(len(words), func(i, j int) { words[i], words[j] = words[j], words[i] })
+	}
+	return &DeterministicSource{words: words, loc: loc}
+}
+
+// forDay starts at the word for now's date; re-rolls move on through the
+// list from there.
+func (ds *DeterministicSource) forDay(now time.Time) *daySource {
+	y, m, d := now.In(ds.loc).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+	return &daySource{words: ds.words, next: int(day % int64(len(ds.words)))}
+}
+
+// Next serves today's word; GetWOTD draws from forDay instead.
+func (ds *DeterministicSource) Next(ctx context.Context) (string, error) {
+	return ds.forDay(time.Now()).Next(ctx)
+}
+
+// daySource is one GetWOTD's walk through a deterministic list.
+type daySource struct {
+	words []string
+	next  int
+}
+
+func (ds *daySource) Next(context.Context) (string, error) {
+	w := ds.words[ds.next%len(ds.words)]
+	ds.next++
+	return w, nil
+}
+
+// Curated source set from WORD_SOURCE=file in main; nil means the random
+// word API.
+var Source WordSource
+
+// source is where GetWOTD draws words for p: the curated list if there is
+// one (from today's word on, with DETERMINISTIC), else the random word API
+// with p's language and lengths.
+func (p Prefs) source() WordSource {
+	if ds, ok := Source.(*DeterministicSource); ok {
+		return ds.forDay(time.Now())
+	}
+	if Source != nil {
+		return Source
+	}
+	return randomSource{prefs: p}
+}
+
+// deterministic reports whether words are picked by date.
+func deterministic() bool {
+	_, ok := Source.(*DeterministicSource)
+	return ok
+}
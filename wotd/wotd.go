@@ -0,0 +1,364 @@
+// Package wotd picks a Word of the Day, looks up its definition and renders
+// it for Discord, independent of the bot itself: main wires it to a session,
+// slash commands and the scheduler. Settings are package variables, set once
+// at startup before any lookup.
+package wotd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ---------------------------
+// API response types
+// ---------------------------
+
+type RandomWordResponse []string
+
+type Definition struct {
+	Definition string   `json:"definition"`
+	Example    string   `json:"example"`
+	Synonyms   []string `json:"synonyms"`
+	Antonyms   []string `json:"antonyms"`
+}
+
+type Meaning struct {
+	PartOfSpeech string       `json:"partOfSpeech"`
+	Definitions  []Definition `json:"definitions"`
+	Synonyms     []string     `json:"synonyms"`
+	Antonyms     []string     `json:"antonyms"`
+}
+
+type Phonetic struct {
+	Text  string `json:"text"`
+	Audio string `json:"audio"`
+}
+
+type WordData struct {
+	Word      string     `json:"word"`
+	Phonetic  string     `json:"phonetic"`
+	Phonetics []Phonetic `json:"phonetics"`
+	Meanings  []Meaning  `json:"meanings"`
+	Etymology string     `json:"-"` // short origin note; only Wiktionary fills it
+	Source    string     `json:"-"` // Name() of the provider that defined it
+	Lang      string     `json:"-"` // language it was looked up in
+}
+
+// ---------------------------
+// Word helpers
+// ---------------------------
+
+// HTTPDoer is the part of *http.Client the fetchers use, so tests can
+// swap in canned responses.
+type HTTPDoer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Shared client for all outbound API calls; timeout, attempts and
+// User-Agent are set from config in main.
+var (
+	HTTPClient            = &http.Client{Timeout: 10 * time.Second}
+	httpDoer     HTTPDoer = HTTPClient
+	HTTPAttempts          = 3
+	UserAgent             = DefaultUserAgent
+)
+
+// Some public APIs turn away Go's default User-Agent, so identify ourselves.
+const DefaultUserAgent = "discord-wotdbot/1.0 (+github.com/mcsharkie/discord-wotdbot)"
+
+// Base delay between retries; doubled after each failed attempt.
+const retryBackoff = 200 * time.Millisecond
+
+// getWithRetry retries the same URL on network errors and 5xx responses with
+// exponential backoff until ctx is done. Any other response is returned to
+// the caller as-is.
+func getWithRetry(ctx context.Context, url string, attempts int) (*http.Response, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := retryBackoff
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", UserAgent)
+		resp, err := httpDoer.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode < 500 {
+			return resp, nil
+		}
+		resp.Body.Close()
+		lastErr = fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return nil, lastErr
+}
+
+// decodeJSON decodes a JSON response body into v. A 200 that isn't JSON,
+// typically a CDN's HTML error page, gets a clear error naming api rather
+// than a decoder complaint about an invalid character '<'. A missing
+// Content-Type is given the benefit of the doubt.
+func decodeJSON(resp *http.Response, api string, v any) error {
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil || mt != "application/json" && !strings.HasSuffix(mt, "+json") {
+			return fmt.Errorf("unexpected content type %s from %s", ct, api)
+		}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Prefs are the word-selection settings for one post: the env config,
+// or a guild's /config overrides on top of it.
+type Prefs struct {
+	Lang           string // language code for random words and definitions
+	Difficulty     Difficulty
+	MinLen, MaxLen int // random word length bounds; 0 means unbounded
+}
+
+// NewPrefs fills the length bounds from the difficulty tier unless
+// WORD_MIN_LENGTH/WORD_MAX_LENGTH set them.
+func NewPrefs(lang string, d Difficulty, minLen, maxLen int) Prefs {
+	if minLen == 0 && maxLen == 0 {
+		minLen, maxLen = d.lengths()
+	}
+	return Prefs{Lang: lang, Difficulty: d, MinLen: minLen, MaxLen: maxLen}
+}
+
+// Prefs used when no guild overrides them; set from config in main.
+var DefaultPrefs = Prefs{Lang: "en"}
+
+// fetchRandomWord asks each of RandomWordAPIs that has words in p's
+// language in turn, so one provider's outage doesn't stop the Word of the
+// Day, and returns the last error if none of them came through.
+func fetchRandomWord(ctx context.Context, p Prefs) (string, error) {
+	lastErr := fmt.Errorf("no random word API configured for %s", p.Lang)
+	for _, api := range RandomWordAPIs {
+		if !api.Supports(p.Lang) {
+			continue
+		}
+		word, err := api.Random(ctx, p)
+		if err == nil {
+			return word, nil
+		}
+		apiFailures.WithLabelValues(api.Name()).Inc()
+		LogFrom(ctx).Debug("[words] random word API failed", "api", api.Name(), "err", err)
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// ---------------------------
+// Word of the Day
+// ---------------------------
+
+// With REQUIRE_DEFINITION, GetWOTD never settles for a word without a
+// definition. Set from config in main.
+var RequireDefinition bool
+
+// Primary definitions shorter than this many characters ("See cat.") are
+// re-rolled; 0 accepts any. Set from MIN_DEF_LENGTH in main.
+var MinDefLength int
+
+// Try up to N words from sel until one has a definition of at least
+// MinDefLength, skipping rejected, recently posted and unfit ones. Falls back
+// to the first word with a too-short definition, else the last selected word
+// with no meanings, preferring one that fit, or an empty WordData if no word
+// came through at all; a recently posted word is never the fallback. It
+// gives up early, with the fallback, once ctx is done. With
+// RequireDefinition a word without a definition is never the fallback: ok is
+// false and the caller should skip posting.
+func GetWOTD(ctx context.Context, retries int, sel Selector, lang string) (w WordData, ok bool) {
+	log := LogFrom(ctx)
+	var fallback string
+	fallbackFits := false
+	var short WordData // first definition under MinDefLength
+	var failures failureTally
+	i := 0
+	for ; i < retries && ctx.Err() == nil; i++ {
+		word, err := sel.Select(ctx)
+		if err != nil {
+			log.Debug("[wotd] candidate skipped", "attempt", i+1, "word", word, "err", err)
+			failures.add(failureKind("random word API error", err))
+		}
+		if errors.Is(err, errUnfit) {
+			if !fallbackFits {
+				fallback = word
+			}
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		fallback, fallbackFits = word, true
+		data, err := FetchDefinition(ctx, word, lang)
+		// A lookup cut short by the caller says nothing about the
+		// dictionaries. An outage counts like an unknown word: either way
+		// the post falls back.
+		if ctx.Err() == nil {
+			Misses.Record(err != nil)
+			if err != nil {
+				definitionMisses.Inc()
+			}
+		}
+		if err != nil {
+			log.Debug("[wotd] no definition", "attempt", i+1, "word", word, "err", err)
+			failures.add(failureKind("dictionary error", err))
+			continue
+		}
+		if _, def, _ := data.Primary(); utf8.RuneCountInString(def.Definition) < MinDefLength {
+			log.Debug("[wotd] definition too short", "attempt", i+1, "word", word)
+			failures.add("short definition")
+			if short.Word == "" {
+				short = data
+			}
+			continue
+		}
+		return data, true
+	}
+	log.Warn("[wotd] no fitting word found, falling back", "attempts", i, "failures", failures.String())
+	if short.Word != "" {
+		return short, true // defined, just briefly; not a fallback
+	}
+	wotdFallbacks.Inc()
+	if RequireDefinition {
+		return WordData{}, false
+	}
+	return WordData{Word: fallback}, true
+}
+
+// failureKind sorts an error from one GetWOTD attempt for the fallback
+// summary; other is the kind for errors it doesn't recognize.
+func failureKind(other string, err error) string {
+	var ne net.Error
+	switch {
+	case errors.Is(err, errRejected):
+		return "rejected word"
+	case errors.Is(err, errUnfit):
+		return "unfit word"
+	case errors.Is(err, errRepeated):
+		return "recent word"
+	case errors.Is(err, ErrNoDefinition):
+		return "dictionary miss"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne) && ne.Timeout():
+		return "network timeout"
+	}
+	return other
+}
+
+// failureTally counts why GetWOTD attempts failed, in first-seen order.
+type failureTally struct {
+	kinds  []string
+	counts map[string]int
+}
+
+func (t *failureTally) add(kind string) {
+	if t.counts == nil {
+		t.counts = map[string]int{}
+	}
+	if t.counts[kind] == 0 {
+		t.kinds = append(t.kinds, kind)
+	}
+	t.counts[kind]++
+}
+
+// String summarizes the tally, e.g. "3 dictionary misses, 1 network timeout".
+func (t failureTally) String() string {
+	var parts []string
+	for _, k := range t.kinds {
+		n := t.counts[k]
+		switch {
+		case n == 1:
+		case strings.HasSuffix(k, "ss"):
+			k += "es"
+		default:
+			k += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, k))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Whether GetWOTD accepts tokens with hyphens, apostrophes, digits and the
+// like. Set from config in main.
+var AllowNonAlpha bool
+
+// plainWord reports whether every rune of word is a letter, so compounds such
+// as "mother-in-law" are re-rolled; they often break dictionary URLs or never
+// resolve. Letters of any script pass, for non-English LANG.
+func plainWord(word string) bool {
+	if AllowNonAlpha {
+		return word != ""
+	}
+	if word == "" {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Look up a user-supplied word for /define. On failure the second result is
+// the message to show instead.
+func DefineWord(ctx context.Context, word, lang string) (WordData, string) {
+	data, err := FetchDefinition(ctx, word, lang)
+	if errors.Is(err, ErrNoDefinition) {
+		return data, fmt.Sprintf("No definition found for %s.", word)
+	}
+	if err != nil {
+		slog.Error("[define] lookup failed", "word", word, "err", err)
+		return data, fmt.Sprintf("⚠️ Could not look up %s right now.", word)
+	}
+	return data, ""
+}
+
+// requestIDKey holds a context's request ID.
+type requestIDKey struct{}
+
+// newRequestID is a short random hex ID, e.g. "3f9a1c07".
+func newRequestID() string {
+	return fmt.Sprintf("%08x", rand.Uint32())
+}
+
+// WithRequestID tags ctx with a fresh request ID, so every log line about
+// one post (word, definition, send) can be found together.
+func WithRequestID(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, newRequestID())
+}
+
+// LogFrom is the default logger, with ctx's request ID attached if any.
+func LogFrom(ctx context.Context) *slog.Logger {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return slog.With("req", id)
+	}
+	return slog.Default()
+}
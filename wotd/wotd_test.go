@@ -0,0 +1,162 @@
+package wotd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// stubDoer answers every request with a fixed status and body.
+type stubDoer struct {
+	status int
+	body   string
+}
+
+func (d stubDoer) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: d.status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(d.body)),
+		Request:    req,
+	}, nil
+}
+
+func withDoer(t *testing.T, d HTTPDoer) {
+	t.Helper()
+	prev := httpDoer
+	httpDoer = d
+	t.Cleanup(func() { httpDoer = prev })
+}
+
+func TestFetchRandomWord(t *testing.T) {
+	tests := []struct {
+		name    string
+		doer    stubDoer
+		want    string
+		wantErr bool
+	}{
+		{"ok", stubDoer{http.StatusOK, `["fortitude"]`}, "fortitude", false},
+		{"empty array", stubDoer{http.StatusOK, `[]`}, "", true},
+		{"not found", stubDoer{http.StatusNotFound, `not found`}, "", true},
+		{"malformed json", stubDoer{http.StatusOK, `["fortitude"`}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDoer(t, tt.doer)
+			got, err := fetchRandomWord(context.Background(), DefaultPrefs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("word = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// hostDoer is a stubDoer that records the hosts asked.
+type hostDoer struct {
+	stubDoer
+	hosts *[]string
+}
+
+func (d hostDoer) Do(req *http.Request) (*http.Response, error) {
+	*d.hosts = append(*d.hosts, req.URL.Host)
+	return d.stubDoer.Do(req)
+}
+
+func TestFetchRandomWordSkipsUnsupportedLanguage(t *testing.T) {
+	prev := RandomWordAPIs
+	RandomWordAPIs = []RandomWordSource{vercelWordAPI{}, herokuWordAPI{}}
+	t.Cleanup(func() { RandomWordAPIs = prev })
+	var hosts []string
+	withDoer(t, hostDoer{stubDoer{http.StatusOK, `["saudade"]`}, &hosts})
+
+	p := DefaultPrefs
+	p.Lang = "pt"
+	if got, err := fetchRandomWord(context.Background(), p); err != nil || got != "saudade" {
+		t.Fatalf("fetchRandomWord(pt) = %q, %v, want saudade from heroku", got, err)
+	}
+	if len(hosts) != 1 || hosts[0] != "random-word-api.herokuapp.com" {
+		t.Errorf("asked %q, want only heroku: the English-only vercel API is skipped, not counted as failed", hosts)
+	}
+}
+
+func TestPlainWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"fortitude", true},
+		{"café", true},
+		{"straße", true},
+		{"mother-in-law", false},
+		{"o'clock", false},
+		{"ice cream", false},
+		{"b2b", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := plainWord(tt.word); got != tt.want {
+			t.Errorf("plainWord(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+// seqSource hands out words in order, then errors.
+type seqSource []string
+
+func (s *seqSource) Next(context.Context) (string, error) {
+	if len(*s) == 0 {
+		return "", errors.New("out of words")
+	}
+	w := (*s)[0]
+	*s = (*s)[1:]
+	return w, nil
+}
+
+// echoProvider defines every word it is asked about and records the calls.
+type echoProvider struct{ asked []string }
+
+func (p *echoProvider) Name() string { return "echo" }
+
+func (p *echoProvider) Define(_ context.Context, word, _ string) (WordData, error) {
+	p.asked = append(p.asked, word)
+	return WordData{Word: word, Meanings: []Meaning{{Definitions: []Definition{{Definition: "x"}}}}}, nil
+}
+
+func TestGetWOTDRerollsNonAlpha(t *testing.T) {
+	src := &seqSource{"mother-in-law", "o'clock", "fortitude"}
+	echo := &echoProvider{}
+	prevSource, prevProviders, prevCache := Source, Providers, Definitions
+	Source, Providers, Definitions = src, []DefinitionProvider{echo}, nil
+	t.Cleanup(func() { Source, Providers, Definitions = prevSource, prevProviders, prevCache })
+
+	if got, _ := GetWOTD(context.Background(), 5, NewSelector(DefaultPrefs, nil, nil), DefaultPrefs.Lang); got.Word != "fortitude" {
+		t.Errorf("GetWOTD = %q, want fortitude", got.Word)
+	}
+	if len(echo.asked) != 1 || echo.asked[0] != "fortitude" {
+		t.Errorf("looked up %q, want only fortitude", echo.asked)
+	}
+}
+
+// wordHistory is a History of fixed words.
+type wordHistory map[string]bool
+
+func (h wordHistory) Contains(word string) bool { return h[word] }
+
+func TestGetWOTDNeverFallsBackToHistory(t *testing.T) {
+	prevSource, prevProviders, prevCache := Source, Providers, Definitions
+	Source, Providers, Definitions = &seqSource{"perspicacious", "tenacity"}, []DefinitionProvider{&downProvider{}}, nil
+	t.Cleanup(func() { Source, Providers, Definitions = prevSource, prevProviders, prevCache })
+
+	// Neither word fits easy, and both were posted before.
+	p := NewPrefs("en", easy, 0, 0)
+	got, _ := GetWOTD(context.Background(), 2, NewSelector(p, wordHistory{"perspicacious": true, "tenacity": true}, nil), p.Lang)
+	if got.Word != "" {
+		t.Errorf("GetWOTD fell back to %q, want no word rather than a repeat", got.Word)
+	}
+}